@@ -0,0 +1,114 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+/*
+	Open-Meteo doesn't publish weather alerts, so they come from the US National Weather Service instead, which only
+	covers the United States.
+*/
+
+const nwsAlertsURL = "https://api.weather.gov/alerts/active"
+
+var errAlertsUnsupported = errors.New("alerts not supported by current provider")
+
+type Alert struct {
+	Title    string
+	Severity string
+	Start    time.Time
+	End      time.Time
+}
+
+type nwsAlerts struct {
+	Features []struct {
+		Properties struct {
+			Event    string `json:"event"`
+			Severity string `json:"severity"`
+			Onset    string `json:"onset"`
+			Ends     string `json:"ends"`
+			Expires  string `json:"expires"`
+		} `json:"properties"`
+	} `json:"features"`
+}
+
+var unitedStatesNames = map[string]bool{"united states": true, "united states of america": true, "us": true, "usa": true}
+
+func fetchAlerts(loc Location) ([]Alert, error) {
+
+	if !unitedStatesNames[strings.ToLower(loc.Country)] {
+		return nil, errAlertsUnsupported
+	}
+
+	var response nwsAlerts
+	if err := getJSON(fmt.Sprintf("%s?point=%.4f,%.4f", nwsAlertsURL, loc.Lat, loc.Lon), &response); err != nil {
+		return nil, err
+	}
+
+	var alerts []Alert
+
+	for _, feature := range response.Features {
+
+		properties := feature.Properties
+
+		alert := Alert{Title: properties.Event, Severity: properties.Severity}
+		alert.Start, _ = time.Parse(time.RFC3339, properties.Onset)
+
+		// alerts without a known end still expire
+		end := properties.Ends
+		if end == "" {
+			end = properties.Expires
+		}
+		alert.End, _ = time.Parse(time.RFC3339, end)
+
+		alerts = append(alerts, alert)
+	}
+
+	return alerts, nil
+}
+
+func formatAlertTime(t time.Time) string {
+
+	if t.IsZero() {
+		return "unknown"
+	}
+
+	t = t.In(displayZone())
+	return formatDay(t) + " " + formatHour(t)
+}
+
+func alerts([]string) (string, error) {
+
+	if offline {
+		return "", errors.New("alerts aren't available in offline mode")
+	}
+
+	if !internalLocation.hasCoordinates() {
+		return "", errors.New(tr("no coordinates known for this location. Try setting it again with setloc"))
+	}
+
+	active, err := fetchAlerts(internalLocation)
+
+	if errors.Is(err, errAlertsUnsupported) {
+		return "  " + err.Error(), nil
+	}
+
+	if err != nil {
+		return "", errors.New("could not fetch alerts: " + err.Error())
+	}
+
+	if len(active) == 0 {
+		return tr("No active alerts."), nil
+	}
+
+	var lines []string
+	for _, alert := range active {
+		lines = append(lines, fmt.Sprintf("%s (%s)", alert.Title, alert.Severity))
+		lines = append(lines, fmt.Sprintf("  %s until %s", formatAlertTime(alert.Start), formatAlertTime(alert.End)))
+	}
+
+	return strings.Join(lines, "\n  "), nil
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+func (r *REPL) setAlias(args []string) (string, error) {
+
+	if len(args) == 0 {
+		if len(config.Aliases) == 0 {
+			return "no aliases defined", nil
+		}
+
+		var names []string
+		for name := range config.Aliases {
+			names = append(names, name)
+		}
+		slices.Sort(names)
+
+		var lines []string
+		for _, name := range names {
+			lines = append(lines, fmt.Sprintf("alias %s \"%s\"", name, config.Aliases[name]))
+		}
+		return strings.Join(lines, "\n  "), nil
+	}
+
+	if len(args) == 1 {
+		return "", errUsage("alias")
+	}
+
+	name := args[0]
+
+	if _, ok := r.Lookup(name); ok {
+		return "", errors.New(name + " is a command and can't be aliased")
+	}
+
+	expansion := strings.TrimSpace(strings.Trim(strings.Join(args[1:], " "), "\"'"))
+
+	if expansion == "" {
+		return "", errors.New("alias " + name + " needs a command to expand to")
+	}
+
+	config.Aliases[name] = expansion
+
+	if err := saveConfig(); err != nil {
+		return "  alias " + name + " set, but could not be saved: " + err.Error(), nil
+	}
+
+	return "  alias " + name + " -> " + expansion, nil
+}
+
+func removeAlias(args []string) (string, error) {
+
+	if len(args) != 1 {
+		return "", errUsage("unalias")
+	}
+
+	if _, ok := config.Aliases[args[0]]; !ok {
+		return "", errors.New("no alias named " + args[0])
+	}
+
+	delete(config.Aliases, args[0])
+
+	if err := saveConfig(); err != nil {
+		return "  alias " + args[0] + " removed, but could not be saved: " + err.Error(), nil
+	}
+
+	return "  removed alias " + args[0], nil
+}
+
+// dropShadowingAliases removes aliases loaded from the config file that are named after a command, which setAlias
+// refuses, so a config edited by hand can't make a command run something else. It warns about each one.
+func (r *REPL) dropShadowingAliases() {
+
+	var names []string
+	for name := range config.Aliases {
+		if _, ok := r.Lookup(name); ok {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+
+	for _, name := range names {
+		printWarning(fmt.Sprintf("ignoring alias %s from the config file, since %s is a command", name, name))
+		delete(config.Aliases, name)
+	}
+}
+
+// resolveAlias expands the first argument while it names an alias. Aliases may refer to other aliases, but not
+// back to one already being expanded.
+func resolveAlias(arguments []string) ([]string, error) {
+
+	seen := map[string]bool{}
+
+	for {
+		name := arguments[0]
+
+		expansion, ok := config.Aliases[name]
+		if !ok {
+			return arguments, nil
+		}
+
+		if seen[name] {
+			return nil, errors.New("alias " + name + " refers to itself")
+		}
+		seen[name] = true
+
+		arguments = append(strings.Fields(expansion), arguments[1:]...)
+
+		// an alias edited to nothing in the config file
+		if len(arguments) == 0 {
+			return nil, errors.New("alias " + name + " doesn't expand to a command")
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestAliasShadowingCommand(t *testing.T) {
+	useTestState(t)
+
+	r := NewREPL()
+	registerBuiltins(r)
+
+	if _, err := r.setAlias([]string{"now", "hours", "3"}); err == nil {
+		t.Error("aliased now, which is a command")
+	}
+	if _, ok := config.Aliases["now"]; ok {
+		t.Error("the refused alias was kept")
+	}
+}
+
+func TestShadowingAliasesFromConfigDropped(t *testing.T) {
+	useTestState(t)
+
+	// as a config file edited by hand would have them
+	config.Aliases = map[string]string{"now": "hours 3", "days": "now", "wk": "days 7"}
+
+	r := NewREPL()
+	registerBuiltins(r)
+	r.dropShadowingAliases()
+
+	if len(config.Aliases) != 1 || config.Aliases["wk"] != "days 7" {
+		t.Errorf("aliases left are %v, want only wk", config.Aliases)
+	}
+
+	arguments, err := resolveAlias([]string{"now"})
+	if err != nil || !slices.Equal(arguments, []string{"now"}) {
+		t.Errorf("now resolved to %q, %v, want the command", arguments, err)
+	}
+}
+
+func TestResolveAlias(t *testing.T) {
+	useTestState(t)
+	config.Aliases = map[string]string{"wk": "days 7", "w": "wk --csv", "loop": "loop2", "loop2": "loop", "blank": " "}
+
+	arguments, err := resolveAlias([]string{"w", "out.csv"})
+	if err != nil || !slices.Equal(arguments, []string{"days", "7", "--csv", "out.csv"}) {
+		t.Errorf("w out.csv resolved to %q, %v", arguments, err)
+	}
+
+	for _, name := range []string{"loop", "blank"} {
+		if arguments, err := resolveAlias([]string{name}); err == nil {
+			t.Errorf("%s resolved to %q, want an error", name, arguments)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+/*
+	Air quality comes from Open-Meteo's separate air quality API, which like its forecast API needs no key. Values use
+	the US EPA's AQI scale, where each pollutant gets its own sub-index and the overall AQI is the worst of them.
+*/
+
+const openMeteoAirQualityURL = "https://air-quality-api.open-meteo.com/v1/air-quality"
+
+// sub-index keys and the pollutants they measure
+var pollutants = [...][2]string{
+	{"us_aqi_pm2_5", "PM2.5"},
+	{"us_aqi_pm10", "PM10"},
+	{"us_aqi_ozone", "ozone"},
+	{"us_aqi_nitrogen_dioxide", "nitrogen dioxide"},
+	{"us_aqi_sulphur_dioxide", "sulphur dioxide"},
+	{"us_aqi_carbon_monoxide", "carbon monoxide"},
+}
+
+type AirQuality struct {
+	Index     float64
+	Pollutant string
+}
+
+func aqiCategory(index float64) string {
+	switch {
+	case index <= 50:
+		return "Good"
+	case index <= 100:
+		return "Moderate"
+	case index <= 150:
+		return "Unhealthy for Sensitive Groups"
+	case index <= 200:
+		return "Unhealthy"
+	case index <= 300:
+		return "Very Unhealthy"
+	default:
+		return "Hazardous"
+	}
+}
+
+func fetchAirQuality(loc Location, at time.Time) (AirQuality, error) {
+
+	hour := at.UTC().Truncate(time.Hour).Format(openMeteoTimeLayout)
+
+	query := url.Values{}
+	query.Set("latitude", strconv.FormatFloat(loc.Lat, 'f', -1, 64))
+	query.Set("longitude", strconv.FormatFloat(loc.Lon, 'f', -1, 64))
+	query.Set("hourly", "us_aqi,us_aqi_pm2_5,us_aqi_pm10,us_aqi_ozone,us_aqi_nitrogen_dioxide,us_aqi_sulphur_dioxide,us_aqi_carbon_monoxide")
+	query.Set("start_hour", hour)
+	query.Set("end_hour", hour)
+
+	var response struct {
+		Hourly map[string][]any `json:"hourly"`
+		Error  bool             `json:"error"`
+		Reason string           `json:"reason"`
+	}
+
+	if err := getJSON(openMeteoAirQualityURL+"?"+query.Encode(), &response); err != nil {
+		return AirQuality{}, err
+	}
+
+	if response.Error {
+		return AirQuality{}, errors.New(response.Reason)
+	}
+
+	first := func(key string) (float64, bool) {
+		values := response.Hourly[key]
+		if len(values) == 0 {
+			return 0, false
+		}
+		value, ok := values[0].(float64)
+		return value, ok
+	}
+
+	index, ok := first("us_aqi")
+	if !ok {
+		return AirQuality{}, errors.New("no air quality data for this time")
+	}
+
+	quality := AirQuality{Index: index}
+	worst := -1.0
+
+	for _, pollutant := range pollutants {
+		if value, ok := first(pollutant[0]); ok && value > worst {
+			worst = value
+			quality.Pollutant = pollutant[1]
+		}
+	}
+
+	return quality, nil
+}
+
+func aqi([]string) (string, error) {
+
+	if offline {
+		return "", errors.New("air quality isn't available in offline mode")
+	}
+
+	if !internalLocation.hasCoordinates() {
+		return "", errors.New(tr("no coordinates known for this location. Try setting it again with setloc"))
+	}
+
+	quality, err := fetchAirQuality(internalLocation, internalTime)
+	if err != nil {
+		return "", errors.New("could not fetch air quality: " + err.Error())
+	}
+
+	rows := [][2]string{
+		{"AQI", fmt.Sprintf("%.0f (%s)", quality.Index, tr(aqiCategory(quality.Index)))},
+	}
+
+	if quality.Pollutant != "" {
+		rows = append(rows, [2]string{tr("Dominant pollutant"), quality.Pollutant})
+	}
+
+	return alignFields(rows), nil
+}
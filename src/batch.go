@@ -0,0 +1,165 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"golang.org/x/term"
+)
+
+/*
+	batch looks up the current weather in every location listed in a file, one "City,Region,Country" a line like
+	--location takes. Its requests go through the same rate limited client as everything else, so a long list slows
+	down rather than going over requests_per_minute.
+*/
+
+type batchResult struct {
+	Line     int
+	Name     string
+	Location Location
+	Weather  Conditions
+	Err      error
+}
+
+// readBatchFile is the location names in path, skipping blank lines and # comments, with their line numbers
+func readBatchFile(path string) (names []string, lines []int, err error) {
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		names = append(names, line)
+		lines = append(lines, n)
+	}
+
+	return names, lines, scanner.Err()
+}
+
+func batch(args []string) (string, error) {
+
+	if len(args) != 1 {
+		return "", errUsage("batch")
+	}
+
+	names, lines, err := readBatchFile(args[0])
+	if err != nil {
+		return "", errors.New("could not read " + args[0] + ": " + err.Error())
+	}
+
+	if len(names) == 0 {
+		return "", errors.New(args[0] + " has no locations in it")
+	}
+
+	progress := newBatchProgress(len(names))
+
+	var results []batchResult
+	for i, name := range names {
+		progress.show(i, name)
+		results = append(results, batchLookup(lines[i], name))
+	}
+
+	progress.done()
+
+	var rows [][]string
+	var failures []string
+
+	for _, result := range results {
+		if result.Err != nil {
+			failures = append(failures, fmt.Sprintf(tr("line %d, %s: %v"), result.Line, result.Name, result.Err))
+			continue
+		}
+
+		loc, c := result.Location, result.Weather
+		rows = append(rows, []string{strings.Join(nonEmpty(loc.City, loc.Region, loc.Country), ", "), formatTemperature(c.Temperature), formatWind(c), conditionIcon(c.Code) + " " + conditionName(c.Code)})
+	}
+
+	if len(rows) == 0 {
+		return "", errors.New(tr("every location failed: ") + strings.Join(failures, "; "))
+	}
+
+	output := layoutTable(rows, []column{{}, {Right: true}, {}, {Flex: true}})
+
+	if len(failures) > 0 {
+		output = append(output, fmt.Sprintf(tr("%d of %d locations failed:"), len(failures), len(results)))
+		for _, failure := range failures {
+			output = append(output, "  "+failure)
+		}
+	}
+
+	return strings.Join(output, "\n  "), nil
+}
+
+// batchLookup finds where one line of a batch file is and the weather there now.
+func batchLookup(line int, name string) batchResult {
+
+	result := batchResult{Line: line, Name: name}
+
+	loc, err := parseLocation(name)
+	if err == nil {
+		loc, err = geocodeParsed(loc)
+	}
+	if errors.Is(err, errNoPlaces) {
+		err = errors.New(tr("no such place"))
+	}
+	if err != nil {
+		result.Err = err
+		return result
+	}
+
+	result.Location = loc
+	result.Weather, result.Err = provider.Current(loc.Lat, loc.Lon)
+	return result
+}
+
+// batchProgress shows how far through a batch is on stderr, on one line that's redrawn at a terminal, and a line at a
+// time otherwise unless weth is quiet.
+type batchProgress struct {
+	total    int
+	started  time.Time
+	terminal bool
+}
+
+func newBatchProgress(total int) *batchProgress {
+	return &batchProgress{total: total, started: time.Now(), terminal: term.IsTerminal(int(os.Stderr.Fd()))}
+}
+
+func (p *batchProgress) show(done int, name string) {
+
+	if !p.terminal && quiet {
+		return
+	}
+
+	line := fmt.Sprintf("%d/%d %s", done+1, p.total, name)
+
+	// the time left is guessed from how long the ones so far took, which the rate limit can only make longer
+	if done > 0 {
+		left := time.Since(p.started) / time.Duration(done) * time.Duration(p.total-done)
+		line += ", " + fmt.Sprintf(tr("about %s left"), left.Round(time.Second))
+	}
+
+	if p.terminal {
+		fmt.Fprintf(os.Stderr, "\r\033[K  %s", line)
+		return
+	}
+	fmt.Fprintf(os.Stderr, "  %s\n", line)
+}
+
+// done clears the progress line away before the results are printed
+func (p *batchProgress) done() {
+	if p.terminal {
+		fmt.Fprint(os.Stderr, "\r\033[K")
+	}
+}
@@ -0,0 +1,49 @@
+package main
+
+// registerBuiltins adds weth's own commands to r, in the order help lists them.
+func registerBuiltins(r *REPL) {
+
+	builtins := []Command{
+		{Name: "now", Help: "weather at TIME, in LOCATION", Run: nowWeather},
+		{Name: "hours", Help: "hourly forecast starting at TIME, or between two times on TIME's date", Run: hoursForecast},
+		{Name: "days", Help: "daily forecast and moon phase starting on TIME's date, or for one date: days on MONTH DAY", Run: daysForecast},
+		{Name: "forecast", Help: "the week ahead at a glance, starting on TIME's date", Run: forecast},
+		{Name: "batch", Help: "the weather now in every location listed in FILE, one a line", Run: batch},
+		{Name: "diff", Help: "how the weather changes between two times, e.g. diff now +4h", Run: diffWeather},
+		{Name: "threshold", Help: "the first hour from TIME the temperature goes below or above a value, e.g. threshold temp-below 15C", Run: threshold},
+		{Name: "alerts", Help: "active weather alerts for LOCATION", Run: alerts},
+		{Name: "aqi", Help: "air quality at TIME, in LOCATION", Run: aqi},
+		{Name: "time", Help: "print TIME", Run: getTime},
+		{Name: "settime", Help: "change TIME", Run: setTime},
+		{Name: "advance", Help: "move TIME forward, e.g. advance 3h", Run: advanceTime},
+		{Name: "rewind", Help: "move TIME back, e.g. rewind 2d", Run: rewindTime},
+		{Name: "resettime", Help: "set TIME back to the real current time", Run: resetTime},
+		{Name: "tz", Help: "show times in another timezone than LOCATION's, e.g. tz UTC", Run: setTimezone},
+		{Name: "loc", Help: "print LOCATION. --detailed adds its coordinates and timezone", Run: getLocation},
+		{Name: "setloc", Help: "change LOCATION", Run: setLocation},
+		{Name: "recent", Help: "list the locations setloc went to last, or go back to one of them", Run: recentLocations},
+		{Name: "units", Help: "show or change units: metric, imperial, or both", Run: setUnits},
+		{Name: "lang", Help: "show or change the language output is shown in", Run: setLanguage},
+		{Name: "provider", Help: "show or change the weather provider", Run: setProvider},
+		{Name: "offline", Help: "turn synthetic weather data on or off", Run: setOffline},
+		{Name: "refresh", Help: "clear cached weather and fetch now again", Run: refresh},
+		{Name: "config", Help: "show or change settings, like how many hours a bare hours shows", Run: setConfig},
+		{Name: "color", Help: "turn colored output on or off", Run: setColor},
+		{Name: "colors", Help: "show or change the temperatures that colors change at", Run: setTemperatureColors},
+		{Name: "raw", Help: "print the provider's response for now, hours or days, as it came back", Run: rawWeather},
+		{Name: "snapshot", Help: "write TIME, LOCATION, settings and the responses weth has had to FILE, for bug reports", Run: snapshot},
+		{Name: "load-snapshot", Help: "go back to what a snapshot FILE shows, without the network", Run: loadSnapshot},
+		{Name: "explain", Help: "say where LOCATION and TIME came from, and which provider and units are in use", Run: explain},
+		{Name: "diagnose", Help: "check the IP lookup, geocoder and weather provider, and how long each takes", Run: diagnose},
+		{Name: "log", Help: "list what was looked up this session and what came back", Run: printLog},
+		{Name: "history", Help: "list previous commands. !N runs entry N again", Run: printHistory},
+		{Name: "alias", Help: "list aliases, or define one: alias NAME \"COMMAND\"", Run: r.setAlias},
+		{Name: "unalias", Help: "remove an alias", Run: removeAlias},
+		{Name: "help", Help: "print this message. help COMMAND shows how to use COMMAND", Run: r.printHelp},
+	}
+
+	for _, command := range builtins {
+		command.Usage = usageStrings[command.Name]
+		r.Register(command)
+	}
+}
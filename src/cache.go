@@ -0,0 +1,119 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+/*
+	Responses from the weather provider are kept in memory for config.CacheTTLMinutes, so running 'now' a few times
+	in a row only hits the API once. Entries are keyed by provider, coordinates, query type, and the hour they start
+	at, so changing the location or the internal time never serves data for the wrong place or time.
+*/
+
+type cacheEntry struct {
+	fetched time.Time
+	value   any
+}
+
+var weatherCache = map[string]cacheEntry{}
+
+// cacheMu guards weatherCache. It isn't held while fetching, so a slow request doesn't hold up other lookups.
+var cacheMu sync.Mutex
+
+// cachedProvider wraps another provider, answering from weatherCache when it can.
+type cachedProvider struct {
+	WeatherProvider
+}
+
+func withCache(p WeatherProvider) WeatherProvider {
+	return cachedProvider{p}
+}
+
+// cacheTTL reads the setting under stateMu, since the background refresh caches what it fetches too
+func cacheTTL() time.Duration {
+	stateMu.RLock()
+	defer stateMu.RUnlock()
+	return time.Duration(config.CacheTTLMinutes) * time.Minute
+}
+
+func cacheKey(p WeatherProvider, kind string, lat float64, lon float64, start time.Time, count int) string {
+	return fmt.Sprintf("%s|%s|%.4f,%.4f|%d|%d", p.Name(), kind, lat, lon, start.UTC().Truncate(time.Hour).Unix(), count)
+}
+
+// cached returns the entry for key if it's still fresh, and fetches and stores it otherwise.
+func cached[T any](key string, fetch func() (T, error)) (T, error) {
+
+	ttl := cacheTTL()
+
+	cacheMu.Lock()
+	if entry, ok := weatherCache[key]; ok {
+		if time.Since(entry.fetched) < ttl {
+			cacheMu.Unlock()
+			return entry.value.(T), nil
+		}
+		delete(weatherCache, key)
+	}
+	cacheMu.Unlock()
+
+	value, err := fetch()
+	if err != nil {
+		return value, err
+	}
+
+	storeCached(key, value)
+	return value, nil
+}
+
+// storeCached puts value in the cache under key, replacing whatever was there, unless caching is off. The background
+// refresh uses it directly to replace entries that are still fresh.
+func storeCached(key string, value any) {
+	if cacheTTL() > 0 {
+		cacheMu.Lock()
+		weatherCache[key] = cacheEntry{fetched: time.Now(), value: value}
+		cacheMu.Unlock()
+	}
+}
+
+func (c cachedProvider) Current(lat float64, lon float64) (Conditions, error) {
+	key := cacheKey(c.WeatherProvider, "current", lat, lon, time.Now(), 1)
+	return cached(key, func() (Conditions, error) {
+		return c.WeatherProvider.Current(lat, lon)
+	})
+}
+
+func (c cachedProvider) Hourly(lat float64, lon float64, start time.Time, count int) ([]Conditions, error) {
+	key := cacheKey(c.WeatherProvider, "hourly", lat, lon, start, count)
+	return cached(key, func() ([]Conditions, error) {
+		return c.WeatherProvider.Hourly(lat, lon, start, count)
+	})
+}
+
+// the past doesn't change, but it's kept no longer than anything else so the cache stays small
+func (c cachedProvider) History(lat float64, lon float64, start time.Time, count int) ([]Conditions, error) {
+	key := cacheKey(c.WeatherProvider, "history", lat, lon, start, count)
+	return cached(key, func() ([]Conditions, error) {
+		return c.WeatherProvider.History(lat, lon, start, count)
+	})
+}
+
+func (c cachedProvider) Daily(lat float64, lon float64, start time.Time, count int) ([]DailyConditions, error) {
+	day := time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, start.Location())
+	key := cacheKey(c.WeatherProvider, "daily", lat, lon, day, count)
+	return cached(key, func() ([]DailyConditions, error) {
+		return c.WeatherProvider.Daily(lat, lon, start, count)
+	})
+}
+
+func clearCache() {
+	cacheMu.Lock()
+	defer cacheMu.Unlock()
+	weatherCache = map[string]cacheEntry{}
+}
+
+// refresh throws away everything cached and fetches the current conditions again.
+func refresh([]string) (string, error) {
+	clearCache()
+	return nowWeather(nil)
+}
@@ -0,0 +1,283 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+/*
+	Coloring is applied as a thin layer over the plain strings the command functions return, right before they're
+	printed. The command functions themselves never emit escape codes, so their output can be compared as-is.
+*/
+
+const (
+	ansiReset   = "\033[0m"
+	ansiRed     = "\033[31m"
+	ansiGreen   = "\033[32m"
+	ansiYellow  = "\033[33m"
+	ansiBlue    = "\033[34m"
+	ansiMagenta = "\033[35m"
+	ansiCyan    = "\033[36m"
+	ansiWhite   = "\033[37m"
+	ansiGray    = "\033[90m"
+)
+
+var colorEnabled bool
+
+// matches temperatures such as "21°C", "-3.5°F"
+var temperaturePattern = regexp.MustCompile(`-?\d+(\.\d+)?°[CF]`)
+
+var conditionColors = map[string]string{
+	"Clear":        ansiYellow,
+	"Sunny":        ansiYellow,
+	"Cloudy":       ansiWhite,
+	"Overcast":     ansiGray,
+	"Fog":          ansiGray,
+	"Drizzle":      ansiCyan,
+	"Rain":         ansiBlue,
+	"Showers":      ansiBlue,
+	"Snow":         ansiWhite,
+	"Thunderstorm": ansiMagenta,
+
+	// the same, translated
+	"Despejado": ansiYellow,
+	"Nublado":   ansiWhite,
+	"Cubierto":  ansiGray,
+	"Niebla":    ansiGray,
+	"Llovizna":  ansiCyan,
+	"Lluvia":    ansiBlue,
+	"Chubascos": ansiBlue,
+	"Nieve":     ansiWhite,
+	"Tormenta":  ansiMagenta,
+}
+
+// matches the trend arrow after a day's high, such as "21°C ↑"
+var trendPattern = regexp.MustCompile(`°[CF]\)? [↑↓]`)
+
+// matches precipitation chances such as "60% precip"
+var precipitationPattern = regexp.MustCompile(`\b(\d+)% precip`)
+
+// matches the tag on hours and days over the wind alert, and the speed it's about
+var windyPattern = regexp.MustCompile(`\((windy|ventoso)\)`)
+var speedPattern = regexp.MustCompile(`\b\d+ (km/h|mph)`)
+
+var conditionPattern = regexp.MustCompile(`\b(Clear|Sunny|Cloudy|Overcast|Fog|Drizzle|Rain|Showers|Snow|Thunderstorm|Despejado|Nublado|Cubierto|Niebla|Llovizna|Lluvia|Chubascos|Nieve|Tormenta)\b`)
+
+// Colors are on by default only when stdout is a terminal and the user hasn't opted out through NO_COLOR.
+// See https://no-color.org
+func initColor(noColorFlag bool) {
+	_, noColorEnv := os.LookupEnv("NO_COLOR")
+	colorEnabled = !noColorFlag && !noColorEnv && term.IsTerminal(int(os.Stdout.Fd()))
+}
+
+func paint(color string, s string) string {
+	return color + s + ansiReset
+}
+
+// the cold to hot gradient temperatures are colored on, with a threshold between each pair of colors
+var temperatureGradient = []string{ansiBlue, ansiCyan, ansiGreen, ansiYellow, ansiRed}
+var temperatureGradientNames = []string{"blue", "cyan", "green", "yellow", "red"}
+
+// where the gradient changes color, in °C for metric and °F for imperial, unless colors sets others
+var defaultTemperatureColors = map[string][]float64{
+	"metric":   {0, 10, 20, 30},
+	"imperial": {32, 50, 68, 86},
+}
+
+// temperatureThresholds is where the gradient changes color in a unit system, falling back to the defaults when the
+// config doesn't have a usable set for it
+func temperatureThresholds(system string) []float64 {
+	thresholds := config.TemperatureColors[system]
+	if validThresholds(thresholds) != nil {
+		return defaultTemperatureColors[system]
+	}
+	return thresholds
+}
+
+// validThresholds is an error unless there's a threshold between each pair of colors, from coldest to hottest
+func validThresholds(thresholds []float64) error {
+	if len(thresholds) != len(temperatureGradient)-1 {
+		return fmt.Errorf("expected %d thresholds, one between each pair of %s", len(temperatureGradient)-1, strings.Join(temperatureGradientNames, ", "))
+	}
+	for i := 1; i < len(thresholds); i++ {
+		if thresholds[i] <= thresholds[i-1] {
+			return errors.New("thresholds go from coldest to hottest, each higher than the one before")
+		}
+	}
+	return nil
+}
+
+// temperatureColor picks a color on the cold to hot gradient. A temperature exactly on a threshold gets the warmer
+// color, so with the default metric thresholds 0°C is cyan and 30°C is red. thresholds and the temperature are in
+// the same units.
+func temperatureColor(thresholds []float64, temperature float64) string {
+	for i, threshold := range thresholds {
+		if temperature < threshold {
+			return temperatureGradient[i]
+		}
+	}
+	return temperatureGradient[len(thresholds)]
+}
+
+// colorTemperature colors a temperature against the thresholds for the unit it's shown in, so with units both the
+// °C and °F next to each other are compared against thresholds of their own
+func colorTemperature(match string) string {
+	value, err := strconv.ParseFloat(strings.TrimSuffix(match[:len(match)-1], "°"), 64)
+	if err != nil {
+		return match
+	}
+
+	system := "metric"
+	if match[len(match)-1] == 'F' {
+		system = "imperial"
+	}
+
+	return paint(temperatureColor(temperatureThresholds(system), value), match)
+}
+
+// warming is red and cooling is blue
+func colorTrend(match string) string {
+	arrow := match[strings.LastIndex(match, " ")+1:]
+	color := ansiBlue
+	if arrow == "↑" {
+		color = ansiRed
+	}
+	return strings.TrimSuffix(match, arrow) + paint(color, arrow)
+}
+
+func colorPrecipitation(match string) string {
+	percent, err := strconv.Atoi(precipitationPattern.FindStringSubmatch(match)[1])
+	if err != nil || percent < precipitationHighlight {
+		return match
+	}
+	return paint(ansiBlue, match)
+}
+
+func colorize(output string) string {
+	if !colorEnabled {
+		return output
+	}
+
+	lines := strings.Split(output, "\n")
+
+	for i, line := range lines {
+		if strings.Contains(line, "Error") {
+			lines[i] = paint(ansiRed, line)
+			continue
+		}
+
+		if windyPattern.MatchString(line) {
+			line = windyPattern.ReplaceAllStringFunc(line, func(match string) string { return paint(ansiRed, match) })
+			line = speedPattern.ReplaceAllStringFunc(line, func(match string) string { return paint(ansiRed, match) })
+		}
+
+		line = trendPattern.ReplaceAllStringFunc(line, colorTrend)
+		line = temperaturePattern.ReplaceAllStringFunc(line, colorTemperature)
+		line = precipitationPattern.ReplaceAllStringFunc(line, colorPrecipitation)
+		line = conditionPattern.ReplaceAllStringFunc(line, func(match string) string {
+			return paint(conditionColors[match], match)
+		})
+		lines[i] = line
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+func setColor(args []string) (string, error) {
+
+	if len(args) == 0 {
+		if colorEnabled {
+			return "color is on", nil
+		}
+		return "color is off", nil
+	}
+
+	switch args[0] {
+	case "on":
+		colorEnabled = true
+		return "color enabled", nil
+	case "off":
+		colorEnabled = false
+		return "color disabled", nil
+	}
+
+	return "", errUsage("color")
+}
+
+// setTemperatureColors shows or changes where temperatures change color. The thresholds are in the units temperatures
+// are shown in, or the unit system named before them.
+func setTemperatureColors(args []string) (string, error) {
+
+	if len(args) == 0 {
+		var lines []string
+		for _, system := range []string{"metric", "imperial"} {
+			lines = append(lines, system+": "+describeThresholds(system))
+		}
+		return strings.Join(lines, "\n  "), nil
+	}
+
+	if len(args) == 1 && args[0] == "reset" {
+		config.TemperatureColors = nil
+		if err := saveConfig(); err != nil {
+			return "temperature colors reset, but could not be saved: " + err.Error(), nil
+		}
+		return "temperature colors reset to the defaults", nil
+	}
+
+	system := "metric"
+	if activeUnits() == "imperial" {
+		system = "imperial"
+	}
+	if args[0] == "metric" || args[0] == "imperial" {
+		system, args = args[0], args[1:]
+	}
+
+	var thresholds []float64
+	for _, arg := range args {
+		value, err := strconv.ParseFloat(strings.TrimSuffix(arg, "°"), 64)
+		if err != nil || math.IsInf(value, 0) || math.IsNaN(value) {
+			return "", errUsage("colors")
+		}
+		thresholds = append(thresholds, value)
+	}
+
+	if err := validThresholds(thresholds); err != nil {
+		return "", err
+	}
+
+	if config.TemperatureColors == nil {
+		config.TemperatureColors = map[string][]float64{}
+	}
+	config.TemperatureColors[system] = thresholds
+
+	if err := saveConfig(); err != nil {
+		return system + " temperature colors set, but could not be saved: " + err.Error(), nil
+	}
+	return system + ": " + describeThresholds(system), nil
+}
+
+// describeThresholds is which temperatures get which color in a unit system, e.g. "blue below 0°C, cyan below 10°C, ..."
+func describeThresholds(system string) string {
+
+	unit := "C"
+	if system == "imperial" {
+		unit = "F"
+	}
+
+	thresholds := temperatureThresholds(system)
+	var parts []string
+
+	for i, threshold := range thresholds {
+		parts = append(parts, fmt.Sprintf("%s below %g°%s", temperatureGradientNames[i], threshold, unit))
+	}
+	parts = append(parts, fmt.Sprintf("%s from %g°%s", temperatureGradientNames[len(thresholds)], thresholds[len(thresholds)-1], unit))
+
+	return strings.Join(parts, ", ")
+}
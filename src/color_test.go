@@ -0,0 +1,82 @@
+package main
+
+import "testing"
+
+func TestTemperatureColor(t *testing.T) {
+
+	tests := []struct {
+		system      string
+		temperature float64
+		want        string
+	}{
+		{"metric", -15, ansiBlue},
+		{"metric", -0.1, ansiBlue},
+		{"metric", 0, ansiCyan},
+		{"metric", 9.9, ansiCyan},
+		{"metric", 10, ansiGreen},
+		{"metric", 20, ansiYellow},
+		{"metric", 29.9, ansiYellow},
+		{"metric", 30, ansiRed},
+		{"metric", 45, ansiRed},
+		{"imperial", 31, ansiBlue},
+		{"imperial", 32, ansiCyan},
+		{"imperial", 50, ansiGreen},
+		{"imperial", 67, ansiGreen},
+		{"imperial", 68, ansiYellow},
+		{"imperial", 86, ansiRed},
+	}
+
+	for _, test := range tests {
+		if got := temperatureColor(defaultTemperatureColors[test.system], test.temperature); got != test.want {
+			t.Errorf("temperatureColor(%v) in %s = %q, want %q", test.temperature, test.system, got, test.want)
+		}
+	}
+}
+
+func TestTemperatureThresholds(t *testing.T) {
+	useTestState(t)
+
+	config.TemperatureColors = map[string][]float64{
+		"metric":   {-10, 5, 15, 25},
+		"imperial": {50, 32, 68, 86},
+	}
+
+	if got := temperatureThresholds("metric"); got[0] != -10 {
+		t.Errorf("metric thresholds are %v, want the configured ones", got)
+	}
+
+	// out of order, so the defaults are used
+	if got := temperatureThresholds("imperial"); got[0] != 32 || got[1] != 50 {
+		t.Errorf("imperial thresholds are %v, want the defaults", got)
+	}
+
+	for _, thresholds := range [][]float64{nil, {0, 10, 20}, {0, 10, 20, 30, 40}, {0, 10, 10, 30}} {
+		if validThresholds(thresholds) == nil {
+			t.Errorf("%v are valid thresholds", thresholds)
+		}
+	}
+}
+
+func TestColorTemperature(t *testing.T) {
+	useTestState(t)
+
+	tests := []struct {
+		match string
+		want  string
+	}{
+		{"0°C", ansiCyan},
+		{"-3.5°C", ansiBlue},
+		{"21°C", ansiYellow},
+
+		// the same temperature in °F is compared against the °F thresholds
+		{"70°F", ansiYellow},
+		{"31°F", ansiBlue},
+		{"86°F", ansiRed},
+	}
+
+	for _, test := range tests {
+		if got := colorTemperature(test.match); got != paint(test.want, test.match) {
+			t.Errorf("colorTemperature(%q) = %q, want it in %q", test.match, got, test.want)
+		}
+	}
+}
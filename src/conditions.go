@@ -0,0 +1,97 @@
+package main
+
+/*
+	Each weather code gets a longer description for 'now' and an icon for every listing. Icons come in an emoji and
+	a plain ASCII form. The emoji are only used alongside color, since terminals without color are also the ones likely
+	to mangle them. The ASCII icons are all two characters wide so columns still line up.
+*/
+
+type conditionDetail struct {
+	Description string
+	Emoji       string
+	ASCII       string
+}
+
+var conditionDetails = map[int]conditionDetail{
+	0:  {"Clear skies", "☀️", "()"},
+	1:  {"Mostly clear skies", "🌤️", "()"},
+	2:  {"Partly cloudy", "⛅", "(~"},
+	3:  {"Overcast skies", "☁️", "~~"},
+	45: {"Foggy", "🌫️", "=="},
+	48: {"Freezing fog", "🌫️", "=="},
+	51: {"Light drizzle", "🌦️", ",,"},
+	53: {"Moderate drizzle", "🌦️", ",,"},
+	55: {"Dense drizzle", "🌧️", ",,"},
+	56: {"Light freezing drizzle", "🌧️", ",*"},
+	57: {"Dense freezing drizzle", "🌧️", ",*"},
+	61: {"Light rain", "🌧️", "//"},
+	63: {"Moderate rain", "🌧️", "//"},
+	65: {"Heavy rain", "🌧️", "//"},
+	66: {"Light freezing rain", "🌧️", "/*"},
+	67: {"Heavy freezing rain", "🌧️", "/*"},
+	71: {"Light snowfall", "🌨️", "**"},
+	73: {"Moderate snowfall", "🌨️", "**"},
+	75: {"Heavy snowfall", "❄️", "**"},
+	77: {"Snow grains", "🌨️", "**"},
+	80: {"Light rain showers", "🌦️", "/~"},
+	81: {"Moderate rain showers", "🌦️", "/~"},
+	82: {"Violent rain showers", "🌧️", "/~"},
+	85: {"Light snow showers", "🌨️", "*~"},
+	86: {"Heavy snow showers", "🌨️", "*~"},
+	95: {"Thunderstorms", "⛈️", "/!"},
+	96: {"Thunderstorms with light hail", "⛈️", "/!"},
+	99: {"Thunderstorms with heavy hail", "⛈️", "/!"},
+}
+
+// conditionIcon falls back to a question mark for codes the table doesn't know yet.
+func conditionIcon(code int) string {
+
+	detail, ok := conditionDetails[code]
+	if !ok {
+		return "??"
+	}
+
+	if colorEnabled {
+		return detail.Emoji
+	}
+	return detail.ASCII
+}
+
+// conditionDescription falls back to the condition's name, which is the raw code when even that's unknown.
+func conditionDescription(code int) string {
+	if detail, ok := conditionDetails[code]; ok {
+		return tr(detail.Description)
+	}
+	return conditionName(code)
+}
+
+// windPhrase names wind speeds roughly along the Beaufort scale. Anything calmer than a light breeze isn't worth
+// mentioning.
+func windPhrase(kmh float64) string {
+	switch {
+	case kmh < 6:
+		return ""
+	case kmh < 20:
+		return tr("a light breeze")
+	case kmh < 39:
+		return tr("a fresh breeze")
+	case kmh < 62:
+		return tr("strong winds")
+	default:
+		return tr("gale-force winds")
+	}
+}
+
+// describeConditions is a sentence like "Partly cloudy with a light breeze", led by its icon.
+func describeConditions(c Conditions) string {
+
+	description := conditionDescription(c.Code)
+
+	if c.WindSpeed != nil {
+		if phrase := windPhrase(*c.WindSpeed); phrase != "" {
+			description += " " + tr("with") + " " + phrase
+		}
+	}
+
+	return conditionIcon(c.Code) + " " + description
+}
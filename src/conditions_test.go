@@ -0,0 +1,105 @@
+package main
+
+import (
+	"testing"
+	"unicode/utf8"
+)
+
+func TestConditionTable(t *testing.T) {
+
+	for code, name := range weatherCodes {
+		detail, ok := conditionDetails[code]
+		if !ok {
+			t.Errorf("code %d (%s) has no description or icons", code, name)
+			continue
+		}
+		if detail.Description == "" || detail.Emoji == "" {
+			t.Errorf("code %d has %+v", code, detail)
+		}
+
+		// so columns of icons line up
+		if utf8.RuneCountInString(detail.ASCII) != 2 {
+			t.Errorf("code %d's ASCII icon %q isn't two characters", code, detail.ASCII)
+		}
+
+		// every language has a word for each condition
+		for language, catalog := range catalogs {
+			if _, ok := catalog[name]; !ok {
+				t.Errorf("code %d's name %q isn't in the %s catalog", code, name, language)
+			}
+			if _, ok := catalog[detail.Description]; !ok {
+				t.Errorf("code %d's description %q isn't in the %s catalog", code, detail.Description, language)
+			}
+		}
+	}
+
+	for code := range conditionDetails {
+		if _, ok := weatherCodes[code]; !ok {
+			t.Errorf("code %d has a description but no name", code)
+		}
+	}
+}
+
+func TestConditionLookups(t *testing.T) {
+	useTestState(t)
+
+	color := colorEnabled
+	t.Cleanup(func() { colorEnabled = color })
+	colorEnabled = false
+
+	tests := []struct {
+		code                           int
+		name, description, icon, emoji string
+	}{
+		{0, "Clear", "Clear skies", "()", "☀️"},
+		{3, "Overcast", "Overcast skies", "~~", "☁️"},
+		{63, "Rain", "Moderate rain", "//", "🌧️"},
+		{99, "Thunderstorm", "Thunderstorms with heavy hail", "/!", "⛈️"},
+
+		// a code the table doesn't know yet
+		{42, "Code 42", "Code 42", "??", "??"},
+	}
+
+	for _, test := range tests {
+		colorEnabled = false
+		if name, description, icon := conditionName(test.code), conditionDescription(test.code), conditionIcon(test.code); name != test.name || description != test.description || icon != test.icon {
+			t.Errorf("code %d is %q, %q, %q, want %q, %q, %q", test.code, name, description, icon, test.name, test.description, test.icon)
+		}
+
+		colorEnabled = true
+		if icon := conditionIcon(test.code); icon != test.emoji {
+			t.Errorf("with color, code %d's icon is %q, want %q", test.code, icon, test.emoji)
+		}
+	}
+
+	language = "es"
+	if name := conditionName(63); name != catalogs["es"]["Rain"] {
+		t.Errorf("in Spanish, code 63 is %q", name)
+	}
+}
+
+func TestDescribeConditions(t *testing.T) {
+	useTestState(t)
+
+	color := colorEnabled
+	t.Cleanup(func() { colorEnabled = color })
+	colorEnabled = false
+
+	tests := []struct {
+		c    Conditions
+		want string
+	}{
+		{Conditions{Code: 2}, "(~ Partly cloudy"},
+		{Conditions{Code: 2, WindSpeed: float(5.9)}, "(~ Partly cloudy"},
+		{Conditions{Code: 2, WindSpeed: float(6)}, "(~ Partly cloudy with a light breeze"},
+		{Conditions{Code: 61, WindSpeed: float(20)}, "// Light rain with a fresh breeze"},
+		{Conditions{Code: 95, WindSpeed: float(39)}, "/! Thunderstorms with strong winds"},
+		{Conditions{Code: 3, WindSpeed: float(62)}, "~~ Overcast skies with gale-force winds"},
+	}
+
+	for _, test := range tests {
+		if got := describeConditions(test.c); got != test.want {
+			t.Errorf("describeConditions = %q, want %q", got, test.want)
+		}
+	}
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+/*
+	Settings that persist between runs live in ~/.config/weth/config.json. Any key missing from the file keeps its
+	default value below.
+*/
+
+type Config struct {
+	HistorySize int               `json:"history_size"`
+	Aliases     map[string]string `json:"aliases"`
+	Units       string            `json:"units,omitempty"`
+	Provider    string            `json:"provider"`
+	Language    string            `json:"language"`
+	APIKey      string            `json:"api_key,omitempty"`
+
+	// providers tried in order when Provider can't answer
+	FallbackProviders []string `json:"fallback_providers,omitempty"`
+
+	// the places setloc went to last, most recent first
+	RecentLocations []Location `json:"recent_locations,omitempty"`
+
+	// units for particular locations, keyed by locationKey, used instead of Units while at them
+	LocationUnits map[string]string `json:"location_units,omitempty"`
+
+	// how long weather responses are reused for. 0 turns caching off
+	CacheTTLMinutes int `json:"cache_ttl_minutes"`
+
+	// how long the location found from the IP address is reused between runs
+	LocationMaxAgeHours int `json:"location_max_age_hours"`
+
+	// how often the current weather at LOCATION is fetched again while the REPL is open. 0 turns it off
+	BackgroundRefreshMinutes int `json:"background_refresh_minutes"`
+
+	// limit on outbound API requests. 0 turns the limit off
+	RequestsPerMinute int `json:"requests_per_minute"`
+
+	// what hours and days show when they're given no count
+	HoursDefault int `json:"hours_default"`
+	DaysDefault  int `json:"days_default"`
+
+	// decimal places temperatures are shown with, 0 or 1
+	TemperatureDecimals int `json:"temperature_decimals"`
+
+	// the temperature now --relative and hours --relative count from, in °C
+	ComfortTemperature float64 `json:"comfort_temperature_c"`
+
+	// what relative values in settime, advance, rewind and diff count from: "time", which is TIME, or "now", the real
+	// current time
+	RelativeAnchor string `json:"relative_anchor"`
+
+	// which forecast hour a TIME between hours uses: "nearest" or "floor", the hour it's in
+	HourSnapping string `json:"hour_snapping"`
+
+	// what Enter on an empty line does in the REPL: "skip" it, or "repeat" the last command
+	EmptyLine string `json:"empty_line"`
+
+	// where the temperature at TIME is shown while the REPL waits for a command: "off", the "prompt", the terminal's
+	// "title", or "both"
+	PromptWeather string `json:"prompt_weather"`
+
+	// decimal places latitudes and longitudes are rounded to wherever they're shown or saved, for privacy
+	CoordinateDecimals int `json:"coordinate_decimals"`
+
+	// where temperatures change color from blue to cyan, green, yellow and red, in °C under "metric" and °F under
+	// "imperial". A unit system missing here uses defaultTemperatureColors
+	TemperatureColors map[string][]float64 `json:"temperature_colors,omitempty"`
+
+	// wind speed, in the units speeds are shown in, that hours and days over it are tagged windy at. 0 turns it off
+	WindAlert int `json:"wind_alert"`
+}
+
+var config = Config{
+	HistorySize: 500,
+	Aliases:     map[string]string{},
+	Provider:    "open-meteo",
+	Language:    "en",
+
+	LocationUnits: map[string]string{},
+
+	CacheTTLMinutes:     10,
+	LocationMaxAgeHours: 6,
+	RequestsPerMinute:   45,
+
+	HoursDefault: 6,
+	DaysDefault:  5,
+	HourSnapping: "nearest",
+	EmptyLine:    "skip",
+
+	PromptWeather: "off",
+
+	ComfortTemperature: 21,
+	RelativeAnchor:     "time",
+	CoordinateDecimals: 2,
+}
+
+func configDir() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(home, ".config", "weth"), nil
+}
+
+func configPath() (string, error) {
+	dir, err := configDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, "config.json"), nil
+}
+
+func loadConfig() {
+
+	path, err := configPath()
+	if err != nil {
+		return
+	}
+
+	body, err := os.ReadFile(path)
+
+	if errors.Is(err, fs.ErrNotExist) {
+		return
+	}
+
+	if err != nil {
+		printWarning(fmt.Sprintf("could not read %s: %v", path, err))
+		return
+	}
+
+	if err := json.Unmarshal(body, &config); err != nil {
+		printWarning(fmt.Sprintf("ignoring malformed config %s: %v", path, err))
+	}
+
+	if config.Aliases == nil {
+		config.Aliases = map[string]string{}
+	}
+
+	if config.LocationUnits == nil {
+		config.LocationUnits = map[string]string{}
+	}
+}
+
+func saveConfig() error {
+
+	path, err := configPath()
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+
+	body, err := json.MarshalIndent(config, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, append(body, '\n'), 0o644)
+}
@@ -0,0 +1,264 @@
+package main
+
+import (
+	"slices"
+	"strings"
+)
+
+/*
+	Countries can be typed as their name, their ISO 3166 alpha-2 or alpha-3 code, or a common alias, so US, USA and
+	United States are all the same place. Names are the ones the geocoder uses.
+*/
+
+type country struct {
+	Code    string // ISO 3166 alpha-2
+	Alpha3  string
+	Name    string
+	Aliases []string
+}
+
+var countries = []country{
+	{"AD", "AND", "Andorra", nil},
+	{"AE", "ARE", "United Arab Emirates", []string{"UAE", "Emirates"}},
+	{"AF", "AFG", "Afghanistan", nil},
+	{"AG", "ATG", "Antigua and Barbuda", []string{"Antigua"}},
+	{"AL", "ALB", "Albania", nil},
+	{"AM", "ARM", "Armenia", nil},
+	{"AO", "AGO", "Angola", nil},
+	{"AR", "ARG", "Argentina", nil},
+	{"AT", "AUT", "Austria", nil},
+	{"AU", "AUS", "Australia", nil},
+	{"AZ", "AZE", "Azerbaijan", nil},
+	{"BA", "BIH", "Bosnia and Herzegovina", []string{"Bosnia"}},
+	{"BB", "BRB", "Barbados", nil},
+	{"BD", "BGD", "Bangladesh", nil},
+	{"BE", "BEL", "Belgium", nil},
+	{"BF", "BFA", "Burkina Faso", nil},
+	{"BG", "BGR", "Bulgaria", nil},
+	{"BH", "BHR", "Bahrain", nil},
+	{"BI", "BDI", "Burundi", nil},
+	{"BJ", "BEN", "Benin", nil},
+	{"BN", "BRN", "Brunei", nil},
+	{"BO", "BOL", "Bolivia", nil},
+	{"BR", "BRA", "Brazil", []string{"Brasil"}},
+	{"BS", "BHS", "Bahamas", []string{"The Bahamas"}},
+	{"BT", "BTN", "Bhutan", nil},
+	{"BW", "BWA", "Botswana", nil},
+	{"BY", "BLR", "Belarus", nil},
+	{"BZ", "BLZ", "Belize", nil},
+	{"CA", "CAN", "Canada", nil},
+	{"CD", "COD", "DR Congo", []string{"Democratic Republic of the Congo", "Congo-Kinshasa"}},
+	{"CF", "CAF", "Central African Republic", nil},
+	{"CG", "COG", "Congo Republic", []string{"Republic of the Congo", "Congo-Brazzaville"}},
+	{"CH", "CHE", "Switzerland", nil},
+	{"CI", "CIV", "Ivory Coast", []string{"Côte d'Ivoire", "Cote d'Ivoire"}},
+	{"CL", "CHL", "Chile", nil},
+	{"CM", "CMR", "Cameroon", nil},
+	{"CN", "CHN", "China", []string{"People's Republic of China", "PRC"}},
+	{"CO", "COL", "Colombia", nil},
+	{"CR", "CRI", "Costa Rica", nil},
+	{"CU", "CUB", "Cuba", nil},
+	{"CV", "CPV", "Cabo Verde", []string{"Cape Verde"}},
+	{"CY", "CYP", "Cyprus", nil},
+	{"CZ", "CZE", "Czechia", []string{"Czech Republic"}},
+	{"DE", "DEU", "Germany", []string{"Deutschland"}},
+	{"DJ", "DJI", "Djibouti", nil},
+	{"DK", "DNK", "Denmark", nil},
+	{"DM", "DMA", "Dominica", nil},
+	{"DO", "DOM", "Dominican Republic", nil},
+	{"DZ", "DZA", "Algeria", nil},
+	{"EC", "ECU", "Ecuador", nil},
+	{"EE", "EST", "Estonia", nil},
+	{"EG", "EGY", "Egypt", nil},
+	{"ER", "ERI", "Eritrea", nil},
+	{"ES", "ESP", "Spain", []string{"España", "Espana"}},
+	{"ET", "ETH", "Ethiopia", nil},
+	{"FI", "FIN", "Finland", nil},
+	{"FJ", "FJI", "Fiji", nil},
+	{"FM", "FSM", "Micronesia", nil},
+	{"FR", "FRA", "France", nil},
+	{"GA", "GAB", "Gabon", nil},
+	{"GB", "GBR", "United Kingdom", []string{"UK", "Great Britain", "Britain", "England", "Scotland", "Wales"}},
+	{"GD", "GRD", "Grenada", nil},
+	{"GE", "GEO", "Georgia", nil},
+	{"GH", "GHA", "Ghana", nil},
+	{"GL", "GRL", "Greenland", nil},
+	{"GM", "GMB", "Gambia", []string{"The Gambia"}},
+	{"GN", "GIN", "Guinea", nil},
+	{"GQ", "GNQ", "Equatorial Guinea", nil},
+	{"GR", "GRC", "Greece", nil},
+	{"GT", "GTM", "Guatemala", nil},
+	{"GW", "GNB", "Guinea-Bissau", nil},
+	{"GY", "GUY", "Guyana", nil},
+	{"HK", "HKG", "Hong Kong", nil},
+	{"HN", "HND", "Honduras", nil},
+	{"HR", "HRV", "Croatia", nil},
+	{"HT", "HTI", "Haiti", nil},
+	{"HU", "HUN", "Hungary", nil},
+	{"ID", "IDN", "Indonesia", nil},
+	{"IE", "IRL", "Ireland", nil},
+	{"IL", "ISR", "Israel", nil},
+	{"IN", "IND", "India", nil},
+	{"IQ", "IRQ", "Iraq", nil},
+	{"IR", "IRN", "Iran", nil},
+	{"IS", "ISL", "Iceland", nil},
+	{"IT", "ITA", "Italy", []string{"Italia"}},
+	{"JM", "JAM", "Jamaica", nil},
+	{"JO", "JOR", "Jordan", nil},
+	{"JP", "JPN", "Japan", nil},
+	{"KE", "KEN", "Kenya", nil},
+	{"KG", "KGZ", "Kyrgyzstan", nil},
+	{"KH", "KHM", "Cambodia", nil},
+	{"KI", "KIR", "Kiribati", nil},
+	{"KM", "COM", "Comoros", nil},
+	{"KN", "KNA", "Saint Kitts and Nevis", nil},
+	{"KP", "PRK", "North Korea", nil},
+	{"KR", "KOR", "South Korea", []string{"Korea"}},
+	{"KW", "KWT", "Kuwait", nil},
+	{"KZ", "KAZ", "Kazakhstan", nil},
+	{"LA", "LAO", "Laos", nil},
+	{"LB", "LBN", "Lebanon", nil},
+	{"LC", "LCA", "Saint Lucia", nil},
+	{"LI", "LIE", "Liechtenstein", nil},
+	{"LK", "LKA", "Sri Lanka", nil},
+	{"LR", "LBR", "Liberia", nil},
+	{"LS", "LSO", "Lesotho", nil},
+	{"LT", "LTU", "Lithuania", nil},
+	{"LU", "LUX", "Luxembourg", nil},
+	{"LV", "LVA", "Latvia", nil},
+	{"LY", "LBY", "Libya", nil},
+	{"MA", "MAR", "Morocco", nil},
+	{"MC", "MCO", "Monaco", nil},
+	{"MD", "MDA", "Moldova", nil},
+	{"ME", "MNE", "Montenegro", nil},
+	{"MG", "MDG", "Madagascar", nil},
+	{"MH", "MHL", "Marshall Islands", nil},
+	{"MK", "MKD", "North Macedonia", []string{"Macedonia"}},
+	{"ML", "MLI", "Mali", nil},
+	{"MM", "MMR", "Myanmar", []string{"Burma"}},
+	{"MN", "MNG", "Mongolia", nil},
+	{"MO", "MAC", "Macao", []string{"Macau"}},
+	{"MR", "MRT", "Mauritania", nil},
+	{"MT", "MLT", "Malta", nil},
+	{"MU", "MUS", "Mauritius", nil},
+	{"MV", "MDV", "Maldives", nil},
+	{"MW", "MWI", "Malawi", nil},
+	{"MX", "MEX", "Mexico", []string{"México"}},
+	{"MY", "MYS", "Malaysia", nil},
+	{"MZ", "MOZ", "Mozambique", nil},
+	{"NA", "NAM", "Namibia", nil},
+	{"NE", "NER", "Niger", nil},
+	{"NG", "NGA", "Nigeria", nil},
+	{"NI", "NIC", "Nicaragua", nil},
+	{"NL", "NLD", "The Netherlands", []string{"Netherlands", "Holland"}},
+	{"NO", "NOR", "Norway", nil},
+	{"NP", "NPL", "Nepal", nil},
+	{"NR", "NRU", "Nauru", nil},
+	{"NZ", "NZL", "New Zealand", nil},
+	{"OM", "OMN", "Oman", nil},
+	{"PA", "PAN", "Panama", nil},
+	{"PE", "PER", "Peru", nil},
+	{"PG", "PNG", "Papua New Guinea", nil},
+	{"PH", "PHL", "Philippines", []string{"The Philippines"}},
+	{"PK", "PAK", "Pakistan", nil},
+	{"PL", "POL", "Poland", nil},
+	{"PR", "PRI", "Puerto Rico", nil},
+	{"PS", "PSE", "Palestinian Territory", []string{"Palestine"}},
+	{"PT", "PRT", "Portugal", nil},
+	{"PW", "PLW", "Palau", nil},
+	{"PY", "PRY", "Paraguay", nil},
+	{"QA", "QAT", "Qatar", nil},
+	{"RO", "ROU", "Romania", nil},
+	{"RS", "SRB", "Serbia", nil},
+	{"RU", "RUS", "Russia", []string{"Russian Federation"}},
+	{"RW", "RWA", "Rwanda", nil},
+	{"SA", "SAU", "Saudi Arabia", nil},
+	{"SB", "SLB", "Solomon Islands", nil},
+	{"SC", "SYC", "Seychelles", nil},
+	{"SD", "SDN", "Sudan", nil},
+	{"SE", "SWE", "Sweden", nil},
+	{"SG", "SGP", "Singapore", nil},
+	{"SI", "SVN", "Slovenia", nil},
+	{"SK", "SVK", "Slovakia", nil},
+	{"SL", "SLE", "Sierra Leone", nil},
+	{"SM", "SMR", "San Marino", nil},
+	{"SN", "SEN", "Senegal", nil},
+	{"SO", "SOM", "Somalia", nil},
+	{"SR", "SUR", "Suriname", nil},
+	{"SS", "SSD", "South Sudan", nil},
+	{"ST", "STP", "São Tomé and Príncipe", []string{"Sao Tome and Principe"}},
+	{"SV", "SLV", "El Salvador", nil},
+	{"SY", "SYR", "Syria", nil},
+	{"SZ", "SWZ", "Eswatini", []string{"Swaziland"}},
+	{"TD", "TCD", "Chad", nil},
+	{"TG", "TGO", "Togo", nil},
+	{"TH", "THA", "Thailand", nil},
+	{"TJ", "TJK", "Tajikistan", nil},
+	{"TL", "TLS", "Timor Leste", []string{"East Timor", "Timor-Leste"}},
+	{"TM", "TKM", "Turkmenistan", nil},
+	{"TN", "TUN", "Tunisia", nil},
+	{"TO", "TON", "Tonga", nil},
+	{"TR", "TUR", "Türkiye", []string{"Turkey", "Turkiye"}},
+	{"TT", "TTO", "Trinidad and Tobago", []string{"Trinidad"}},
+	{"TV", "TUV", "Tuvalu", nil},
+	{"TW", "TWN", "Taiwan", nil},
+	{"TZ", "TZA", "Tanzania", nil},
+	{"UA", "UKR", "Ukraine", nil},
+	{"UG", "UGA", "Uganda", nil},
+	{"US", "USA", "United States", []string{"United States of America", "America", "U.S.", "U.S.A."}},
+	{"UY", "URY", "Uruguay", nil},
+	{"UZ", "UZB", "Uzbekistan", nil},
+	{"VA", "VAT", "Vatican", []string{"Vatican City", "Holy See"}},
+	{"VC", "VCT", "Saint Vincent and the Grenadines", nil},
+	{"VE", "VEN", "Venezuela", nil},
+	{"VN", "VNM", "Vietnam", []string{"Viet Nam"}},
+	{"VU", "VUT", "Vanuatu", nil},
+	{"WS", "WSM", "Samoa", nil},
+	{"XK", "XKX", "Kosovo", nil},
+	{"YE", "YEM", "Yemen", nil},
+	{"ZA", "ZAF", "South Africa", nil},
+	{"ZM", "ZMB", "Zambia", nil},
+	{"ZW", "ZWE", "Zimbabwe", nil},
+}
+
+// lookupCountry finds the country name is the name, code or an alias of, ignoring case and surrounding spaces.
+func lookupCountry(name string) (country, bool) {
+
+	name = strings.TrimSpace(name)
+
+	for _, c := range countries {
+		if strings.EqualFold(name, c.Code) || strings.EqualFold(name, c.Alpha3) || strings.EqualFold(name, c.Name) {
+			return c, true
+		}
+		for _, alias := range c.Aliases {
+			if strings.EqualFold(name, alias) {
+				return c, true
+			}
+		}
+	}
+
+	return country{}, false
+}
+
+// normalizeCountry gives loc the canonical name and code of its country, when it's one lookupCountry knows. Anything
+// else is left as it was typed, for the geocoder to make what it can of.
+func normalizeCountry(loc Location) Location {
+
+	if c, ok := lookupCountry(loc.Country); ok {
+		loc.Country = c.Name
+		loc.CountryCode = c.Code
+	}
+
+	return loc
+}
+
+// imperialCountries are the countries where temperatures are usually in Fahrenheit. Everywhere else uses metric.
+var imperialCountries = []string{"US", "PR", "LR", "MM", "BS", "BZ", "PW"}
+
+// countryUnits are the units a country usually uses, by its alpha-2 code. Unknown countries get metric.
+func countryUnits(code string) string {
+	if slices.Contains(imperialCountries, strings.ToUpper(code)) {
+		return "imperial"
+	}
+	return "metric"
+}
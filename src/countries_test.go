@@ -0,0 +1,119 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLookupCountry(t *testing.T) {
+
+	tests := []struct {
+		name string
+		code string
+	}{
+		{"US", "US"},
+		{"usa", "US"},
+		{"United States", "US"},
+		{"united states of america", "US"},
+		{"U.S.A.", "US"},
+		{"  GB ", "GB"},
+		{"GBR", "GB"},
+		{"United Kingdom", "GB"},
+		{"uk", "GB"},
+		{"Turkey", "TR"},
+		{"Türkiye", "TR"},
+		{"Timor-Leste", "TL"},
+		{"Holy See", "VA"},
+	}
+
+	for _, test := range tests {
+		c, ok := lookupCountry(test.name)
+		if !ok || c.Code != test.code {
+			t.Errorf("lookupCountry(%q) = %s, %t, want %s", test.name, c.Code, ok, test.code)
+		}
+	}
+
+	for _, name := range []string{"", "Atlantis", "U", "United"} {
+		if c, ok := lookupCountry(name); ok {
+			t.Errorf("lookupCountry(%q) found %s", name, c.Name)
+		}
+	}
+}
+
+func TestNormalizeCountry(t *testing.T) {
+
+	loc := normalizeCountry(Location{City: "Austin", Region: "Texas", Country: "usa"})
+	if loc.Country != "United States" || loc.CountryCode != "US" || loc.City != "Austin" || loc.Region != "Texas" {
+		t.Errorf("normalizeCountry gave %+v", loc)
+	}
+
+	// whatever it doesn't know is left for the geocoder as it was typed
+	loc = normalizeCountry(Location{City: "Ys", Country: "Kingdom of Ys"})
+	if loc.Country != "Kingdom of Ys" || loc.CountryCode != "" {
+		t.Errorf("normalizeCountry changed an unknown country to %+v", loc)
+	}
+}
+
+func TestCountryTableUnique(t *testing.T) {
+
+	seen := map[string]string{}
+	for _, c := range countries {
+		for _, name := range append([]string{c.Code, c.Alpha3, c.Name}, c.Aliases...) {
+			if other, ok := seen[strings.ToLower(name)]; ok && other != c.Code {
+				t.Errorf("%q is both %s and %s", name, other, c.Code)
+			}
+			seen[strings.ToLower(name)] = c.Code
+		}
+	}
+}
+
+func TestCountryUnits(t *testing.T) {
+
+	tests := []struct {
+		code string
+		want string
+	}{
+		{"US", "imperial"},
+		{"us", "imperial"},
+		{"LR", "imperial"},
+		{"MM", "imperial"},
+		{"FR", "metric"},
+		{"GB", "metric"},
+		{"", "metric"},
+		{"XX", "metric"},
+	}
+
+	for _, test := range tests {
+		if got := countryUnits(test.code); got != test.want {
+			t.Errorf("countryUnits(%q) = %s, want %s", test.code, got, test.want)
+		}
+	}
+}
+
+func TestUnitsFromCountry(t *testing.T) {
+	useTestState(t)
+	config.Units = ""
+
+	tests := []struct {
+		country string
+		want    string
+	}{
+		{"United States", "imperial"},
+		{"USA", "imperial"},
+		{"France", "metric"},
+	}
+
+	for _, test := range tests {
+		countryDefaultUnits = countryUnits(normalizeCountry(Location{Country: test.country}).CountryCode)
+		if got := activeUnits(); got != test.want {
+			t.Errorf("units in %s are %s, want %s", test.country, got, test.want)
+		}
+	}
+
+	// they're only a default, whatever units has chosen comes first
+	countryDefaultUnits = "imperial"
+	config.Units = "metric"
+	if got := activeUnits(); got != "metric" {
+		t.Errorf("units are %s after choosing metric, want metric", got)
+	}
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"encoding/csv"
+	"errors"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// csvFlag takes --csv and an optional file name out of args, given either as --csv=FILE or as the argument after
+// --csv. That argument is only taken for the file name when the command couldn't have meant it as one of its own, so
+// hours --csv 24 is 24 hours to the screen. Without a file name, CSV goes to stdout.
+func csvFlag(args []string) (rest []string, path string, found bool) {
+
+	for i := 0; i < len(args); i++ {
+
+		if name, ok := strings.CutPrefix(args[i], "--csv="); ok {
+			found, path = true, name
+			continue
+		}
+
+		if args[i] != "--csv" {
+			rest = append(rest, args[i])
+			continue
+		}
+
+		found = true
+		if i+1 < len(args) && !forecastArgument(args[i+1]) {
+			path = args[i+1]
+			i++
+		}
+	}
+
+	return rest, path, found
+}
+
+// forecastArgument is whether arg reads as something hours or days takes: a flag, a number, a time of day, a day of
+// the month, a month, or the on of days on
+func forecastArgument(arg string) bool {
+
+	if strings.HasPrefix(arg, "-") || arg == "on" {
+		return true
+	}
+
+	if _, err := strconv.ParseFloat(arg, 64); err == nil {
+		return true
+	}
+
+	if _, _, err := parseClock(arg); err == nil {
+		return true
+	}
+
+	if _, err := parseDay(arg); err == nil {
+		return true
+	}
+
+	_, err := parseMonth(arg)
+	return err == nil
+}
+
+// csvNumber formats an optional value, leaving the cell empty when it's missing.
+func csvNumber(value *float64, convert func(float64) float64) string {
+	if value == nil {
+		return ""
+	}
+	return strconv.FormatFloat(convert(*value), 'f', 1, 64)
+}
+
+func csvSpeed(kmh float64) float64 {
+	if activeUnits() == "imperial" {
+		return kmhToMph(kmh)
+	}
+	return kmh
+}
+
+func csvPrecipitation(mm float64) float64 {
+	if activeUnits() == "imperial" {
+		return mm / 25.4
+	}
+	return mm
+}
+
+func unchanged(value float64) float64 {
+	return value
+}
+
+func hourlyRecords(hours []Conditions) [][]string {
+
+	temperatureUnit, speedUnit := "C", "km/h"
+	if activeUnits() == "imperial" {
+		temperatureUnit, speedUnit = "F", "mph"
+	}
+
+	records := [][]string{{"time", "temperature_" + temperatureUnit, "condition", "humidity_percent", "wind_" + speedUnit, "wind_direction_degrees", "precipitation_chance_percent"}}
+
+	for _, hour := range hours {
+		temperature := hour.Temperature
+		records = append(records, []string{
+			hour.Time.In(displayZone()).Format(time.RFC3339),
+			csvNumber(&temperature, displayTemperature),
+			conditionName(hour.Code),
+			csvNumber(hour.Humidity, unchanged),
+			csvNumber(hour.WindSpeed, csvSpeed),
+			csvNumber(hour.WindDirection, unchanged),
+			csvNumber(hour.PrecipitationChance, unchanged),
+		})
+	}
+
+	return records
+}
+
+func dailyRecords(days []DailyConditions) [][]string {
+
+	temperatureUnit, amountUnit := "C", "mm"
+	if activeUnits() == "imperial" {
+		temperatureUnit, amountUnit = "F", "in"
+	}
+
+	records := [][]string{{"date", "high_" + temperatureUnit, "low_" + temperatureUnit, "condition", "precipitation_chance_percent", "precipitation_" + amountUnit}}
+
+	for _, day := range days {
+		high, low := day.High, day.Low
+		records = append(records, []string{
+			day.Date.Format(time.DateOnly),
+			csvNumber(&high, displayTemperature),
+			csvNumber(&low, displayTemperature),
+			conditionName(day.Code),
+			csvNumber(day.PrecipitationChance, unchanged),
+			csvNumber(day.Precipitation, csvPrecipitation),
+		})
+	}
+
+	return records
+}
+
+// writeCSV writes records to path, or straight to stdout when path is empty so the output stays valid CSV.
+func writeCSV(path string, records [][]string) (string, error) {
+
+	if path == "" {
+		if err := csv.NewWriter(os.Stdout).WriteAll(records); err != nil {
+			return "", errors.New("could not write CSV: " + err.Error())
+		}
+		return "", nil
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return "", errors.New("could not write " + path + ": " + err.Error())
+	}
+
+	writer := csv.NewWriter(file)
+	writer.WriteAll(records)
+
+	if err := writer.Error(); err != nil {
+		file.Close()
+		return "", errors.New("could not write " + path + ": " + err.Error())
+	}
+
+	if err := file.Close(); err != nil {
+		return "", errors.New("could not write " + path + ": " + err.Error())
+	}
+
+	return "  wrote " + strconv.Itoa(len(records)-1) + " rows to " + path, nil
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestCSVFlag(t *testing.T) {
+
+	tests := []struct {
+		args  []string
+		rest  []string
+		path  string
+		found bool
+	}{
+		{[]string{"24"}, []string{"24"}, "", false},
+		{[]string{"24", "--csv"}, []string{"24"}, "", true},
+		{[]string{"24", "--csv", "forecast.csv"}, []string{"24"}, "forecast.csv", true},
+		{[]string{"--csv", "forecast.csv", "24"}, []string{"24"}, "forecast.csv", true},
+
+		// the argument after --csv is the command's when it could be
+		{[]string{"--csv", "24"}, []string{"24"}, "", true},
+		{[]string{"--csv", "9am", "5pm"}, []string{"9am", "5pm"}, "", true},
+		{[]string{"--csv", "17:30"}, []string{"17:30"}, "", true},
+		{[]string{"--csv", "--day", "2"}, []string{"--day", "2"}, "", true},
+		{[]string{"--csv", "on", "june", "3rd"}, []string{"on", "june", "3rd"}, "", true},
+		{[]string{"--csv", "--relative"}, []string{"--relative"}, "", true},
+
+		// unless it's given with =
+		{[]string{"--csv=24", "12"}, []string{"12"}, "24", true},
+		{[]string{"12", "--csv=out/june.csv"}, []string{"12"}, "out/june.csv", true},
+	}
+
+	for _, test := range tests {
+		rest, path, found := csvFlag(test.args)
+		if !slices.Equal(rest, test.rest) || path != test.path || found != test.found {
+			t.Errorf("csvFlag(%q) = %q, %q, %v, want %q, %q, %v", test.args, rest, path, found, test.rest, test.path, test.found)
+		}
+	}
+}
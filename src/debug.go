@@ -0,0 +1,82 @@
+package main
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+/*
+	Debug logging is off unless --verbose is passed or WETH_DEBUG=1 is set. When it's on, every outbound request is
+	logged to stderr, so it stays out of the way of piped output.
+*/
+
+var debugLog = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+func initDebugLog(verbose bool) {
+	if verbose || os.Getenv("WETH_DEBUG") == "1" {
+		debugLog = slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	}
+}
+
+// loggingDoer logs each request that goes through it, with its status and how long it took.
+type loggingDoer struct {
+	inner Doer
+}
+
+func newLoggingDoer(inner Doer) loggingDoer {
+	return loggingDoer{inner}
+}
+
+// redactedURL hides the API key and rounds any coordinates like loc --detailed does, so logs can be shared in bug
+// reports.
+func redactedURL(u *url.URL) string {
+
+	query := u.Query()
+	if !query.Has("apikey") && !query.Has("latitude") && !query.Has("longitude") && !query.Has("point") {
+		return u.String()
+	}
+
+	if query.Has("apikey") {
+		query.Set("apikey", "REDACTED")
+	}
+
+	for _, name := range []string{"latitude", "longitude"} {
+		if degrees, err := strconv.ParseFloat(query.Get(name), 64); err == nil {
+			query.Set(name, strconv.FormatFloat(roundCoordinate(degrees), 'f', -1, 64))
+		}
+	}
+
+	// the weather.gov alerts API takes both as point=lat,lon
+	if lat, lon, ok := strings.Cut(query.Get("point"), ","); ok {
+		latDegrees, latErr := strconv.ParseFloat(lat, 64)
+		lonDegrees, lonErr := strconv.ParseFloat(lon, 64)
+		if latErr == nil && lonErr == nil {
+			query.Set("point", strings.ReplaceAll(formatCoordinates(latDegrees, lonDegrees), " ", ""))
+		}
+	}
+
+	redacted := *u
+	redacted.RawQuery = query.Encode()
+	return redacted.String()
+}
+
+func (l loggingDoer) Do(req *http.Request) (*http.Response, error) {
+
+	started := time.Now()
+	response, err := l.inner.Do(req)
+	elapsed := time.Since(started)
+
+	if err != nil {
+		debugLog.Debug("request failed", "method", req.Method, "url", redactedURL(req.URL), "duration", elapsed, "error", err)
+		return response, err
+	}
+
+	debugLog.Debug("request", "method", req.Method, "url", redactedURL(req.URL), "status", response.StatusCode, "duration", elapsed)
+	return response, nil
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+/*
+	diagnose checks each service weth depends on in turn, so a problem can be pinned on the network, the IP lookup,
+	the geocoder or the weather provider. The weather check goes around the cache, so it always makes a request.
+*/
+
+type checkResult struct {
+	Name    string
+	Elapsed time.Duration
+	Detail  string
+	Err     error
+	Skipped bool
+}
+
+// timed runs check, recording how long it took
+func timed(name string, check func() (string, error)) checkResult {
+	started := time.Now()
+	detail, err := check()
+	return checkResult{Name: name, Elapsed: time.Since(started), Detail: detail, Err: err}
+}
+
+func (c checkResult) String() string {
+
+	switch {
+	case c.Skipped:
+		return fmt.Sprintf("%-10s %-7s %7s  %s", c.Name, "SKIPPED", "", c.Detail)
+	case c.Err != nil:
+		return fmt.Sprintf("%-10s %-7s %7s  %v", c.Name, "FAILED", c.Elapsed.Round(time.Millisecond), c.Err)
+	}
+	return fmt.Sprintf("%-10s %-7s %7s  %s", c.Name, "OK", c.Elapsed.Round(time.Millisecond), c.Detail)
+}
+
+func diagnose([]string) (string, error) {
+
+	var results []checkResult
+
+	if offline {
+		results = append(results, checkResult{Name: "IP lookup", Skipped: true, Detail: "offline mode"})
+	} else {
+		results = append(results, timed("IP lookup", func() (string, error) {
+			loc, err := lookupIPLocation()
+			return fmt.Sprintf("%s %s, %s", loc.City, loc.Region, loc.Country), err
+		}))
+	}
+
+	results = append(results, timed("Geocoder", func() (string, error) {
+		loc, err := geocode(internalLocation)
+		return loc.City + ": " + formatCoordinates(loc.Lat, loc.Lon), err
+	}))
+
+	weather := uncached(provider)
+
+	results = append(results, timed("Weather", func() (string, error) {
+		c, err := weather.Current(internalLocation.Lat, internalLocation.Lon)
+		return fmt.Sprintf("%s: %s, %s", weather.Name(), formatTemperature(c.Temperature), conditionName(c.Code)), err
+	}))
+
+	var lines []string
+	failed := 0
+
+	for _, result := range results {
+		lines = append(lines, result.String())
+		if result.Err != nil {
+			failed++
+		}
+	}
+
+	if failed > 0 {
+		lines = append(lines, fmt.Sprintf("%d of %d checks failed", failed, len(results)))
+	} else {
+		lines = append(lines, "everything is working")
+	}
+
+	return strings.Join(lines, "\n  "), nil
+}
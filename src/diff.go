@@ -0,0 +1,152 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+// diffWeather compares the weather at two times, e.g. diff now +4h, a line for each thing that's measured at both.
+func diffWeather(args []string) (string, error) {
+
+	args, base := anchorFlag(args)
+
+	if len(args) != 2 {
+		return "", errUsage("diff")
+	}
+
+	if !internalLocation.hasCoordinates() {
+		return "", errors.New(tr("no coordinates known for this location. Try setting it again with setloc"))
+	}
+
+	var times [2]time.Time
+	var weather [2]Conditions
+
+	for i, arg := range args {
+
+		t, err := parseTimeReference(arg, base)
+		if err != nil {
+			return "", err
+		}
+
+		if err := withinWindow(t); err != nil {
+			return "", err
+		}
+
+		c, _, err := conditionsAt(t)
+		if err != nil {
+			return "", err
+		}
+
+		times[i], weather[i] = t.In(displayZone()), c
+	}
+
+	from, to := weather[0], weather[1]
+
+	rows := [][2]string{
+		{tr("Temperature"), formatTemperature(from.Temperature) + " → " + formatTemperature(to.Temperature) + " (" + temperatureChange(from.Temperature, to.Temperature) + ")"},
+	}
+
+	if from.Code == to.Code {
+		rows = append(rows, [2]string{tr("Conditions"), conditionName(from.Code) + " (" + tr("no change") + ")"})
+	} else {
+		rows = append(rows, [2]string{tr("Conditions"), conditionName(from.Code) + " → " + conditionName(to.Code)})
+	}
+
+	if from.WindSpeed != nil && to.WindSpeed != nil {
+		rows = append(rows, [2]string{tr("Wind"), formatSpeed(*from.WindSpeed) + " → " + formatSpeed(*to.WindSpeed) + " (" + speedChange(*from.WindSpeed, *to.WindSpeed) + ")"})
+	}
+
+	if from.PrecipitationChance != nil && to.PrecipitationChance != nil {
+		change := math.RoundToEven(*to.PrecipitationChance) - math.RoundToEven(*from.PrecipitationChance)
+		rows = append(rows, [2]string{tr("Precipitation"), fmt.Sprintf("%.0f%% → %.0f%% (%+.0f%% %s)", *from.PrecipitationChance, *to.PrecipitationChance, change, changeArrow(change))})
+	}
+
+	header := formatTime(times[0]) + " → " + formatTime(times[1])
+
+	return header + "\n  " + alignFields(rows), nil
+}
+
+// withinWindow is an error when t is further from the real current time than the provider has weather for
+func withinWindow(t time.Time) error {
+
+	history, ahead := provider.Window()
+	now := time.Now()
+
+	switch {
+	case t.After(now.Add(ahead)):
+		return fmt.Errorf(tr("%s is further ahead than %s forecasts, which is %s"), formatTime(t.In(displayZone())), provider.Name(), plural(int(ahead.Hours()/24), "day"))
+	case history > 0 && t.Before(now.Add(-history)):
+		return fmt.Errorf(tr("%s is further back than %s has weather for, which is %s"), formatTime(t.In(displayZone())), provider.Name(), plural(int(history.Hours()/24), "day"))
+	}
+	return nil
+}
+
+// changeArrow points the way a change went, like the trends days shows
+func changeArrow(change float64) string {
+	switch {
+	case change > 0:
+		return "↑"
+	case change < 0:
+		return "↓"
+	}
+	return "→"
+}
+
+// temperatureChange is how much a temperature went up or down, e.g. "+4°C ↑"
+func temperatureChange(from float64, to float64) string {
+	text, change := temperatureDifference(from, to)
+	return text + " " + changeArrow(change)
+}
+
+// temperatureDifference is to minus from, worked out from the temperatures as they're shown so it always adds up,
+// e.g. "+4°C". change is the difference in the units shown first.
+func temperatureDifference(from float64, to float64) (text string, change float64) {
+
+	signed := func(change float64, unit string) string {
+		return fmt.Sprintf("%+.*f°%s", config.TemperatureDecimals, change, unit)
+	}
+
+	celsius := roundTemperature(to) - roundTemperature(from)
+	fahrenheit := roundTemperature(celsiusToFahrenheit(to)) - roundTemperature(celsiusToFahrenheit(from))
+
+	switch activeUnits() {
+	case "imperial":
+		return signed(fahrenheit, "F"), fahrenheit
+	case "both":
+		return signed(celsius, "C") + ", " + signed(fahrenheit, "F"), celsius
+	}
+	return signed(celsius, "C"), celsius
+}
+
+// warmerOrCooler is how much warmer or cooler to is than from, as they're shown, e.g. "+3°C warmer". It's same when
+// they're shown the same.
+func warmerOrCooler(from float64, to float64, same string) string {
+
+	text, change := temperatureDifference(from, to)
+
+	switch {
+	case change > 0:
+		return text + " " + tr("warmer")
+	case change < 0:
+		return text + " " + tr("cooler")
+	}
+	return same
+}
+
+// speedChange is how much a wind speed went up or down, from the speeds as they're shown, e.g. "+8 km/h ↑"
+func speedChange(from float64, to float64) string {
+
+	// rounded the way %.0f rounds them for formatSpeed
+	kmh := math.RoundToEven(to) - math.RoundToEven(from)
+	mph := math.RoundToEven(kmhToMph(to)) - math.RoundToEven(kmhToMph(from))
+
+	switch activeUnits() {
+	case "imperial":
+		return fmt.Sprintf("%+.0f mph %s", mph, changeArrow(mph))
+	case "both":
+		return fmt.Sprintf("%+.0f km/h, %+.0f mph %s", kmh, mph, changeArrow(kmh))
+	}
+	return fmt.Sprintf("%+.0f km/h %s", kmh, changeArrow(kmh))
+}
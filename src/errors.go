@@ -0,0 +1,39 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+)
+
+// usageError is returned by a command that was used wrong. Its text is the command's usage, which is printed as it
+// is rather than as an error message.
+type usageError struct {
+	text string
+}
+
+func (u usageError) Error() string {
+	return u.text
+}
+
+// errUsage is the usage of one of weth's own commands, as an error
+func errUsage(command string) error {
+	return usageError{usage(command)}
+}
+
+// printError prints a command's error to stderr, indented like the output of commands.
+func printError(err error) {
+
+	var usage usageError
+	if errors.As(err, &usage) {
+		fmt.Fprintln(os.Stderr, usage.text)
+		return
+	}
+
+	fmt.Fprintln(os.Stderr, colorize("  Error: "+err.Error()))
+}
+
+// printWarning is for problems a command carries on past, printed to stderr so it stays out of piped output.
+func printWarning(text string) {
+	fmt.Fprintln(os.Stderr, colorize("  Warning: "+text))
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// explain says where LOCATION and TIME came from and what the weather is shown with, for when it isn't obvious why
+// weth shows the weather it does. diagnose checks that the services work; this only describes weth's own state.
+func explain([]string) (string, error) {
+
+	loc := internalLocation
+
+	lines := []string{
+		tr("Location") + ": " + strings.Join(nonEmpty(loc.City, loc.Region, loc.Country), ", "),
+		"  " + locationSource,
+	}
+
+	if loc.hasCoordinates() {
+		lines = append(lines, "  "+fmt.Sprintf(tr("at %s, where the timezone is %s"), formatCoordinates(loc.Lat, loc.Lon), nonEmpty(loc.Timezone, "UTC")[0]))
+	} else {
+		lines = append(lines, "  "+tr("no coordinates known, so there's no weather for it. Try setting it again with setloc"))
+	}
+
+	lines = append(lines, tr("Time")+": "+printTime())
+
+	if offset := time.Since(internalTime); offset.Abs() < time.Minute {
+		lines = append(lines, "  "+tr("the real current time"))
+	} else {
+		lines = append(lines, "  "+fmt.Sprintf(tr("moved from the real current time with settime, advance or rewind, %s"), relativeToNow(internalTime)))
+	}
+
+	if zoneOverride != nil {
+		lines = append(lines, "  "+fmt.Sprintf(tr("shown in %s, set with tz"), zoneOverride))
+	} else {
+		lines = append(lines, "  "+fmt.Sprintf(tr("shown on LOCATION's clock, %s"), displayZone()))
+	}
+
+	lines = append(lines, tr("Provider")+": "+explainProvider())
+	lines = append(lines, tr("Units")+": "+activeUnits()+", "+explainUnits())
+
+	return strings.Join(lines, "\n  "), nil
+}
+
+func explainProvider() string {
+
+	if _, replaying := httpClient.(replayDoer); replaying {
+		return provider.Name() + ", " + tr("answering from a loaded snapshot")
+	}
+
+	if offline {
+		return provider.Name() + ", " + tr("since offline mode is on")
+	}
+
+	text := provider.Name()
+	if f, ok := uncached(provider).(fallbackProvider); ok {
+		var fallbacks []string
+		for _, p := range f.chain[1:] {
+			fallbacks = append(fallbacks, p.Name())
+		}
+		text += ", " + fmt.Sprintf(tr("falling back to %s"), strings.Join(fallbacks, ", "))
+	}
+	return text
+}
+
+// explainUnits says which of the places units can come from activeUnits got them from
+func explainUnits() string {
+
+	if snapshotUnits != "" {
+		return tr("from the loaded snapshot")
+	}
+
+	if _, ok := config.LocationUnits[locationKey(internalLocation)]; ok {
+		return tr("saved for this location with units --for-location")
+	}
+
+	if config.Units != "" {
+		return tr("chosen with units")
+	}
+
+	if unitsCountry != "" {
+		return fmt.Sprintf(tr("the usual units in %s, where your IP address is"), unitsCountry)
+	}
+
+	return tr("the default, until units picks them")
+}
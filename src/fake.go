@@ -0,0 +1,184 @@
+package main
+
+import (
+	"hash/fnv"
+	"math"
+	"strings"
+	"time"
+)
+
+// offline mode makes up all of its data, so it doesn't need to reach any API
+var offline bool
+
+var offlineLocation = Location{
+	City:     "San Francisco",
+	Region:   "California",
+	Country:  "United States",
+	Timezone: "America/Los_Angeles",
+	Lat:      37.7749,
+	Lon:      -122.4194,
+}
+
+// fakeGeocode places a location somewhere on the map based on its name, so the same name always lands in the
+// same spot.
+func fakeGeocode(loc Location) Location {
+
+	hash := fnv.New32a()
+	hash.Write([]byte(strings.ToLower(loc.City + "," + loc.Region + "," + loc.Country)))
+	sum := hash.Sum32()
+
+	loc.Lat = float64(sum%12000)/100 - 60
+	loc.Lon = float64(sum/12000%36000)/100 - 180
+	loc.Timezone = "UTC"
+
+	return loc
+}
+
+func setOffline(args []string) (string, error) {
+
+	if len(args) == 0 {
+		if offline {
+			return "offline mode is on: weather data is synthetic", nil
+		}
+		return "offline mode is off", nil
+	}
+
+	switch args[0] {
+	case "on":
+		updateState(func() {
+			offline = true
+			provider = withCache(newFakeProvider(""))
+		})
+		return "  offline mode enabled: weather data is now synthetic", nil
+	case "off":
+		updateState(func() { offline = false })
+		initProvider()
+		return "  offline mode disabled, using provider " + provider.Name(), nil
+	}
+
+	return "", errUsage("offline")
+}
+
+// fakeProvider makes up weather without touching the network. The same place and time always gets the same
+// conditions, so output built on it is stable.
+type fakeProvider struct{}
+
+func newFakeProvider(string) WeatherProvider {
+	return fakeProvider{}
+}
+
+// synthetic weather can be made up for any time, so the window is as wide as TIME will reasonably go
+func (fakeProvider) Window() (time.Duration, time.Duration) {
+	return 100 * 365 * 24 * time.Hour, 100 * 365 * 24 * time.Hour
+}
+
+func (fakeProvider) HasDaily() bool {
+	return true
+}
+
+func (fakeProvider) Name() string {
+	return "fake"
+}
+
+// a daily cycle peaking mid afternoon, drifting warmer and cooler over the days, and shifted a little by latitude so
+// different places differ
+func (fakeProvider) conditionsAt(lat float64, lon float64, t time.Time) Conditions {
+
+	hour := float64(t.Hour())
+	temperature := 15 - lat/10 + 8*math.Sin((hour-9)*math.Pi/12) + 4*math.Sin(float64(t.YearDay())/3)
+
+	humidity := 60 - 20*math.Sin((hour-9)*math.Pi/12)
+	wind := 10 + 5*math.Cos(hour*math.Pi/6)
+	gusts := wind * (1.5 + 0.3*math.Sin(hour*math.Pi/4))
+	direction := math.Mod(float64(t.YearDay())*37+lon, 360)
+	if direction < 0 {
+		direction += 360
+	}
+	pressure := 1013 + 6*math.Sin(float64(t.Unix()/3600)/18)
+	uv := math.Max(0, 8*math.Sin((hour-6)*math.Pi/12))
+	chance := math.Mod(float64(t.YearDay()*13+t.Hour()*7), 100)
+
+	codes := []int{0, 1, 2, 3, 61, 80}
+
+	return Conditions{
+		Time:        t,
+		Code:        codes[(t.YearDay()+t.Hour()/6)%len(codes)],
+		Temperature: math.Round(temperature*10) / 10,
+		Humidity:    &humidity,
+		WindSpeed:   &wind,
+
+		WindGusts:     &gusts,
+		WindDirection: &direction,
+		Pressure:      &pressure,
+		UVIndex:       &uv,
+
+		PrecipitationChance: &chance,
+	}
+}
+
+func (p fakeProvider) Current(lat float64, lon float64) (Conditions, error) {
+	return p.conditionsAt(lat, lon, time.Now().UTC().Truncate(time.Hour)), nil
+}
+
+func (p fakeProvider) Hourly(lat float64, lon float64, start time.Time, count int) ([]Conditions, error) {
+
+	start = start.UTC().Truncate(time.Hour)
+
+	var hours []Conditions
+	for i := 0; i < count; i++ {
+		hours = append(hours, p.conditionsAt(lat, lon, start.Add(time.Duration(i)*time.Hour)))
+	}
+
+	return hours, nil
+}
+
+// History is made up the same way as the forecast, so the past and future join up
+func (p fakeProvider) History(lat float64, lon float64, start time.Time, count int) ([]Conditions, error) {
+	return p.Hourly(lat, lon, start, count)
+}
+
+// Normals are the days' highs and lows without the drift over the days, so forecasts wander either side of them
+func (fakeProvider) Normals(lat float64, lon float64, start time.Time, count int) ([]DailyNormal, error) {
+
+	first := time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, time.UTC)
+
+	var normals []DailyNormal
+	for i := 0; i < count; i++ {
+		normals = append(normals, DailyNormal{Date: first.AddDate(0, 0, i), High: 23 - lat/10, Low: 7 - lat/10})
+	}
+
+	return normals, nil
+}
+
+func (p fakeProvider) Daily(lat float64, lon float64, start time.Time, count int) ([]DailyConditions, error) {
+
+	first := time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, time.UTC)
+
+	var days []DailyConditions
+	for i := 0; i < count; i++ {
+
+		date := first.AddDate(0, 0, i)
+		hours, _ := p.Hourly(lat, lon, date, 24)
+
+		day := DailyConditions{Date: date, Code: hours[15].Code, High: hours[0].Temperature, Low: hours[0].Temperature}
+		chance, precipitation, wind := 0.0, 0.0, 0.0
+
+		for _, hour := range hours {
+			day.High = math.Max(day.High, hour.Temperature)
+			day.Low = math.Min(day.Low, hour.Temperature)
+			chance = math.Max(chance, *hour.PrecipitationChance)
+			wind = math.Max(wind, *hour.WindSpeed)
+		}
+
+		if chance >= 50 {
+			precipitation = chance / 20
+		}
+
+		day.PrecipitationChance = &chance
+		day.Precipitation = &precipitation
+		day.WindSpeed = &wind
+		days = append(days, day)
+	}
+
+	return days, nil
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"errors"
+	"slices"
+	"time"
+)
+
+/*
+	With fallback_providers in the config file, a request the provider can't answer goes to each fallback in turn,
+	so one service being down or rate limited doesn't stop weth. Which provider answered is logged with --verbose.
+*/
+
+type fallbackProvider struct {
+	chain []WeatherProvider
+}
+
+// firstAnswer asks each provider in the chain in order, returning the first answer. If they all fail, the error is
+// the last one's.
+func firstAnswer[T any](f fallbackProvider, kind string, ask func(p WeatherProvider) (T, error)) (T, error) {
+
+	var answer T
+	var err error
+
+	for _, p := range f.chain {
+
+		answer, err = ask(p)
+		if err == nil {
+			debugLog.Debug("weather served", "provider", p.Name(), "kind", kind)
+			return answer, nil
+		}
+
+		debugLog.Debug("provider failed", "provider", p.Name(), "kind", kind, "error", err, "rate_limited", errors.Is(err, errRateLimited))
+	}
+
+	return answer, err
+}
+
+// Name is the first provider's, since that's the one normally answering
+func (f fallbackProvider) Name() string {
+	return f.chain[0].Name()
+}
+
+func (f fallbackProvider) Current(lat float64, lon float64) (Conditions, error) {
+	return firstAnswer(f, "current", func(p WeatherProvider) (Conditions, error) {
+		return p.Current(lat, lon)
+	})
+}
+
+func (f fallbackProvider) Hourly(lat float64, lon float64, start time.Time, count int) ([]Conditions, error) {
+	return firstAnswer(f, "hourly", func(p WeatherProvider) ([]Conditions, error) {
+		return p.Hourly(lat, lon, start, count)
+	})
+}
+
+// History is errNoHistory only when none of the providers have past weather. Otherwise a failure is the last error
+// from a provider that does, rather than errNoHistory from one after it.
+func (f fallbackProvider) History(lat float64, lon float64, start time.Time, count int) ([]Conditions, error) {
+
+	historyErr := errNoHistory
+
+	hours, err := firstAnswer(f, "history", func(p WeatherProvider) ([]Conditions, error) {
+		hours, err := p.History(lat, lon, start, count)
+		if err != nil && !errors.Is(err, errNoHistory) {
+			historyErr = err
+		}
+		return hours, err
+	})
+
+	if err != nil {
+		return nil, historyErr
+	}
+	return hours, nil
+}
+
+// Daily skips the providers without daily forecasts
+func (f fallbackProvider) Daily(lat float64, lon float64, start time.Time, count int) ([]DailyConditions, error) {
+	return firstAnswer(f, "daily", func(p WeatherProvider) ([]DailyConditions, error) {
+		if !p.HasDaily() {
+			return nil, errors.New(p.Name() + " has no daily forecasts")
+		}
+		return p.Daily(lat, lon, start, count)
+	})
+}
+
+// HasDaily is true when any of the providers has daily forecasts, so days only adds up hours when none do
+func (f fallbackProvider) HasDaily() bool {
+	return slices.ContainsFunc(f.chain, WeatherProvider.HasDaily)
+}
+
+// Window is as wide as any of the providers reach, since a request outside the first's can still be answered
+func (f fallbackProvider) Window() (time.Duration, time.Duration) {
+
+	var history, forecast time.Duration
+	for _, p := range f.chain {
+		h, ahead := p.Window()
+		history, forecast = max(history, h), max(forecast, ahead)
+	}
+	return history, forecast
+}
+
+// Normals come from the first provider that knows them and answers
+func (f fallbackProvider) Normals(lat float64, lon float64, start time.Time, count int) ([]DailyNormal, error) {
+
+	err := errors.New(f.Name() + " doesn't know the usual weather")
+
+	for _, p := range f.chain {
+		if known, ok := p.(NormalsProvider); ok {
+			var normals []DailyNormal
+			if normals, err = known.Normals(lat, lon, start, count); err == nil {
+				return normals, nil
+			}
+		}
+	}
+
+	return nil, err
+}
+
+// Raw comes from the first provider that can show its responses and answers
+func (f fallbackProvider) Raw(kind string, lat float64, lon float64, start time.Time, count int) ([]byte, error) {
+
+	err := errors.New(f.Name() + " can't show its responses")
+
+	for _, p := range f.chain {
+		if raw, ok := p.(RawProvider); ok {
+			var body []byte
+			if body, err = raw.Raw(kind, lat, lon, start, count); err == nil {
+				return body, nil
+			}
+		}
+	}
+
+	return nil, err
+}
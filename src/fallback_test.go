@@ -0,0 +1,146 @@
+package main
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// failingProvider fails every request with err, keeping count of them
+type failingProvider struct {
+	name  string
+	err   error
+	calls *int
+
+	daily   bool
+	history time.Duration
+	ahead   time.Duration
+}
+
+func (p failingProvider) fail() error {
+	*p.calls++
+	return p.err
+}
+
+func (p failingProvider) Name() string { return p.name }
+
+func (p failingProvider) Current(float64, float64) (Conditions, error) {
+	return Conditions{}, p.fail()
+}
+
+func (p failingProvider) Hourly(float64, float64, time.Time, int) ([]Conditions, error) {
+	return nil, p.fail()
+}
+
+func (p failingProvider) Daily(float64, float64, time.Time, int) ([]DailyConditions, error) {
+	return nil, p.fail()
+}
+
+func (p failingProvider) HasDaily() bool { return p.daily }
+
+func (p failingProvider) History(float64, float64, time.Time, int) ([]Conditions, error) {
+	return nil, p.fail()
+}
+
+func (p failingProvider) Window() (time.Duration, time.Duration) { return p.history, p.ahead }
+
+func TestFallbackToNextProvider(t *testing.T) {
+	useTestState(t)
+
+	var calls int
+	down := failingProvider{name: "down", err: errors.New("down"), calls: &calls, daily: true}
+	f := fallbackProvider{chain: []WeatherProvider{down, fakeProvider{}}}
+
+	if c, err := f.Current(1, 2); err != nil || c.Time.IsZero() {
+		t.Errorf("Current = %+v, %v, want the fake provider's", c, err)
+	}
+	if hours, err := f.Hourly(1, 2, time.Now(), 3); err != nil || len(hours) != 3 {
+		t.Errorf("Hourly = %d hours, %v, want 3", len(hours), err)
+	}
+	if days, err := f.Daily(1, 2, time.Now(), 2); err != nil || len(days) != 2 {
+		t.Errorf("Daily = %d days, %v, want 2", len(days), err)
+	}
+
+	if calls != 3 {
+		t.Errorf("the first provider was asked %d times, want 3", calls)
+	}
+
+	// it's named for the first provider, which normally answers
+	if f.Name() != "down" {
+		t.Errorf("Name = %s", f.Name())
+	}
+}
+
+func TestFallbackAllFail(t *testing.T) {
+	useTestState(t)
+
+	var first, second int
+	f := fallbackProvider{chain: []WeatherProvider{
+		failingProvider{name: "limited", err: errRateLimited, calls: &first},
+		failingProvider{name: "down", err: errors.New("down"), calls: &second},
+	}}
+
+	// the error is the last provider's
+	if _, err := f.Current(1, 2); err == nil || err.Error() != "down" {
+		t.Errorf("err = %v, want the last provider's", err)
+	}
+	if first != 1 || second != 1 {
+		t.Errorf("asked the providers %d and %d times, want once each", first, second)
+	}
+}
+
+func TestFallbackDailySkipsProvidersWithout(t *testing.T) {
+	useTestState(t)
+
+	var calls int
+	hourlyOnly := failingProvider{name: "hourly-only", err: errors.New("down"), calls: &calls}
+	f := fallbackProvider{chain: []WeatherProvider{hourlyOnly, fakeProvider{}}}
+
+	if _, err := f.Daily(1, 2, time.Now(), 2); err != nil {
+		t.Fatal(err)
+	}
+
+	// a provider without daily forecasts isn't asked for them
+	if calls != 0 {
+		t.Errorf("the provider without daily forecasts was asked %d times", calls)
+	}
+
+	if !f.HasDaily() {
+		t.Error("HasDaily is false, though the fake provider has them")
+	}
+	if (fallbackProvider{chain: []WeatherProvider{hourlyOnly}}).HasDaily() {
+		t.Error("HasDaily is true with no provider that has them")
+	}
+}
+
+func TestFallbackHistory(t *testing.T) {
+	useTestState(t)
+
+	var calls int
+	noHistory := failingProvider{name: "forecasts", err: errNoHistory, calls: &calls}
+	down := failingProvider{name: "down", err: errors.New("down"), calls: &calls}
+
+	// errNoHistory only when none of them have past weather
+	if _, err := (fallbackProvider{chain: []WeatherProvider{noHistory, noHistory}}).History(1, 2, time.Now(), 2); !errors.Is(err, errNoHistory) {
+		t.Errorf("with no history anywhere, err = %v, want errNoHistory", err)
+	}
+	if _, err := (fallbackProvider{chain: []WeatherProvider{down, noHistory}}).History(1, 2, time.Now(), 2); err == nil || err.Error() != "down" {
+		t.Errorf("with a provider that has history but failed, err = %v, want its error", err)
+	}
+	if hours, err := (fallbackProvider{chain: []WeatherProvider{noHistory, fakeProvider{}}}).History(1, 2, time.Now().Add(-5*time.Hour), 2); err != nil || len(hours) != 2 {
+		t.Errorf("History = %d hours, %v, want the fake provider's 2", len(hours), err)
+	}
+}
+
+func TestFallbackWindow(t *testing.T) {
+
+	var calls int
+	f := fallbackProvider{chain: []WeatherProvider{
+		failingProvider{name: "short", calls: &calls, history: 48 * time.Hour, ahead: 24 * time.Hour},
+		failingProvider{name: "long", calls: &calls, ahead: 16 * 24 * time.Hour},
+	}}
+
+	if history, ahead := f.Window(); history != 48*time.Hour || ahead != 16*24*time.Hour {
+		t.Errorf("Window = %v, %v, want the widest of each", history, ahead)
+	}
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+/*
+	now --field NAME prints one value of the weather now on its own, for shell scripts, e.g. now --field temp prints
+	18°C. With --json it's an object with just that value in it, under the key now --json gives it.
+*/
+
+type nowField struct {
+	Name string
+	Key  string // the value's key in now --json
+
+	// Value is nil when the provider didn't report it
+	Value func(c Conditions) any
+	Text  func(c Conditions) string
+}
+
+// reported is the value p points to, or nil without the pointer, so it doesn't become a non-nil any
+func reported(p *float64) any {
+	if p == nil {
+		return nil
+	}
+	return *p
+}
+
+var nowFields = []nowField{
+	{
+		Name:  "temp",
+		Key:   "temperature_c",
+		Value: func(c Conditions) any { return c.Temperature },
+		Text:  func(c Conditions) string { return formatTemperature(c.Temperature) },
+	},
+	{
+		Name:  "feels-like",
+		Key:   "feels_like_c",
+		Value: func(c Conditions) any { return feelsLike(c) },
+		Text:  func(c Conditions) string { return formatTemperature(feelsLike(c)) },
+	},
+	{
+		Name:  "humidity",
+		Key:   "humidity_percent",
+		Value: func(c Conditions) any { return reported(c.Humidity) },
+		Text:  func(c Conditions) string { return fmt.Sprintf("%.0f%%", *c.Humidity) },
+	},
+	{
+		Name:  "dew-point",
+		Key:   "dew_point_c",
+		Value: func(c Conditions) any { return reported(dewPoint(c)) },
+		Text:  func(c Conditions) string { return formatTemperature(*dewPoint(c)) },
+	},
+	{
+		Name:  "wind",
+		Key:   "wind_kmh",
+		Value: func(c Conditions) any { return reported(c.WindSpeed) },
+		Text:  formatWind,
+	},
+	{
+		Name:  "condition",
+		Key:   "condition",
+		Value: func(c Conditions) any { return conditionName(c.Code) },
+		Text:  func(c Conditions) string { return conditionName(c.Code) },
+	},
+	{
+		Name:  "pressure",
+		Key:   "pressure_hpa",
+		Value: func(c Conditions) any { return reported(c.Pressure) },
+		Text:  func(c Conditions) string { return formatPressure(*c.Pressure) },
+	},
+	{
+		Name:  "uv",
+		Key:   "uv_index",
+		Value: func(c Conditions) any { return reported(c.UVIndex) },
+		Text:  func(c Conditions) string { return fmt.Sprintf("%.0f", *c.UVIndex) },
+	},
+}
+
+func lookupNowField(name string) (nowField, bool) {
+	for _, field := range nowFields {
+		if field.Name == name {
+			return field, true
+		}
+	}
+	return nowField{}, false
+}
+
+func nowFieldNames() []string {
+	var names []string
+	for _, field := range nowFields {
+		names = append(names, field.Name)
+	}
+	return names
+}
+
+// fieldFlag takes --field NAME out of args. ok is false when there's no --field.
+func fieldFlag(args []string) (rest []string, field nowField, ok bool, err error) {
+
+	for i, arg := range args {
+
+		if arg != "--field" {
+			continue
+		}
+
+		if i+1 == len(args) {
+			return args, nowField{}, true, errUsage("now")
+		}
+
+		field, found := lookupNowField(args[i+1])
+		if !found {
+			return args, nowField{}, true, errors.New("no field named " + args[i+1] + ". Fields are " + strings.Join(nowFieldNames(), ", "))
+		}
+
+		return append(args[:i:i], args[i+2:]...), field, true, nil
+	}
+
+	return args, nowField{}, false, nil
+}
+
+// printField prints field of c on its own, as text or with --json as an object
+func printField(c Conditions, field nowField) (string, error) {
+
+	value := field.Value(c)
+	if value == nil {
+		return "", errors.New(provider.Name() + " didn't report " + field.Name + " for " + printTime())
+	}
+
+	if jsonOutput {
+		return writeJSON(map[string]any{field.Key: value})
+	}
+
+	fmt.Println(field.Text(c))
+	return "", nil
+}
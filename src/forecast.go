@@ -0,0 +1,42 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// forecast is a week at a glance: one row per day, starting on TIME's date.
+func forecast([]string) (string, error) {
+
+	days, err := fetchDaily(internalTime, 7)
+	if err != nil {
+		return "", err
+	}
+
+	if jsonOutput {
+		return writeJSON(days)
+	}
+
+	rows := [][]string{{tr("Day"), tr("High"), tr("Low"), tr("Weather"), tr("Rain")}}
+
+	for _, day := range days {
+
+		chance := "-"
+		if day.PrecipitationChance != nil {
+			chance = fmt.Sprintf("%.0f%%", *day.PrecipitationChance)
+		}
+
+		rows = append(rows, []string{
+			weekdayAbbreviations[language][day.Date.Weekday()],
+			formatTemperature(day.High),
+			formatTemperature(day.Low),
+			conditionIcon(day.Code) + " " + conditionName(day.Code),
+			chance,
+		})
+	}
+
+	// numbers line up on the right, words on the left
+	columns := []column{{}, {Right: true}, {Right: true}, {Flex: true}, {Right: true}}
+
+	return strings.Join(layoutTable(rows, columns), "\n  "), nil
+}
@@ -0,0 +1,233 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const helpIntro = `weth reports weather for an internal TIME and LOCATION.
+  Both start out as the real current time and the location found from your IP address, and can be changed with
+  settime and setloc. Every weather command uses them.`
+
+// usageStrings holds the detailed usage of weth's own commands, shown by help <command> and <command> --help
+var usageStrings = map[string]string{
+	"now": `usage: now [--oneline] [--field NAME] [--relative]
+    weather at TIME, in LOCATION. --oneline fits it on one line, for status bars
+    --relative also shows how much warmer or cooler it is than config comfort-temp
+    --field prints just one value, for scripts: temp, feels-like, humidity, dew-point, wind, condition, pressure or uv
+    a TIME between hours uses the nearest forecast hour, or the hour it's in with config hour-snapping floor
+    a TIME that's already over shows the weather that happened, marked (historical)`,
+	"hours": `usage: hours [COUNT]
+    COUNT hours of forecast starting at TIME. Without COUNT, the hours-default setting is used
+    0 or 1 hours shows the weather now. Hours that are already over are past weather, marked (historical)
+  or:    hours <START> <END>
+    every hour from START to END on TIME's date, e.g. hours 9am 5pm or hours 9 17:30
+  or:    hours --day <OFFSET> [COUNT] [START]
+    COUNT hours of the day OFFSET days after TIME's date, from START, e.g. hours --day 2 12 9am
+    without COUNT and START it's the whole day from midnight
+    add --csv [FILE] to any form to write the forecast as CSV to FILE, or to the screen. --csv=FILE always takes
+    FILE as the file, even when it could be a number or time
+    add --relative to any form to show how much warmer or cooler each hour is than config comfort-temp`,
+	"days": `usage: days [COUNT]
+    COUNT days of forecast and moon phases starting on TIME's date. Without COUNT, the days-default setting is used
+    the arrow after each high shows whether it's warmer or cooler than the day before
+  or:    days on <MONTH> <DAY>
+    the forecast for one date, e.g. days on june 3
+    add --csv [FILE] to either form to write the forecast as CSV to FILE, or to the screen. --csv=FILE always
+    takes FILE as the file, even when it could be a number or date
+    add --with-normals to show each day's usual high and low, averaged over past years, and how much warmer or
+    cooler than usual the day is`,
+	"forecast": `usage: forecast
+    a table of the 7 days starting on TIME's date: high, low, weather, and chance of rain`,
+	"batch": `usage: batch <FILE>
+    look up the weather now in every location in FILE, written one a line as City,Region,Country like --location
+    blank lines and lines starting with # are skipped. Progress is shown on stderr, and a location that can't be
+    found or fetched is listed at the end instead of stopping the rest`,
+	"diff": `usage: diff <FROM> <TO>
+    compare the weather at two times: the temperature, conditions, wind and chance of precipitation
+    each time can be now, a shift from TIME like +4h or -1d, a time of day on TIME's date like 5pm, or a timestamp
+    like 2024-06-03T14:30:00
+    with --from-now, now and the shifts are the real current time's instead of TIME's, and --from-time undoes that`,
+	"threshold": `usage: threshold <temp-below|temp-above> <TEMPERATURE>
+    the first hour from TIME on that the temperature is below or above TEMPERATURE, looking up to a week ahead
+    TEMPERATURE can say its unit, e.g. 15C or 59F, and is otherwise in the units temperatures are shown in`,
+	"alerts": `usage: alerts
+    active weather alerts for LOCATION. Only available in the United States`,
+	"aqi": `usage: aqi
+    air quality at TIME, in LOCATION, on the US EPA scale`,
+	"time": `usage: time
+    print TIME, and how far it is from the real current time`,
+	"settime": `usage: settime <HOUR> <DAY> <MONTH> <YEAR>
+    HOUR is 0-23 or 12-hour like 5pm, DAY can be an ordinal like 3rd, MONTH is a number or a name like june or jun
+    a MONTH name can go before DAY, and a "the" before DAY is left out, e.g. settime 9 june the 3rd 2024
+    any value can be * to leave it alone, or /N to move it N forward (/-N goes back)
+    a two-digit YEAR from 00 to 68 is 20xx, and from 69 to 99 is 19xx, so 24 is 2024
+    e.g. settime 5pm 3 june 2025, settime * /1 * *
+    with --from-now, /N and * count from the real current time instead of TIME, e.g. settime /6 * * * --from-now
+  or:    settime --iso=<TIMESTAMP>
+    e.g. settime --iso=2024-06-03T14:30:00 or settime --iso=2024-06-03T14:30:00-07:00
+  or:    settime --wizard
+    asks for each value in turn. Press Enter to keep the value in brackets
+  or:    settime --military=<true|false>
+    show hours on a 24-hour clock, or a 12-hour one
+  or:    settime
+    set TIME back to the real current time`,
+	"advance": `usage: advance <DURATION> [--from-now|--from-time]
+    move TIME forward by DURATION, e.g. advance 3h, advance 2d or advance 1h30m
+    DURATION is a number followed by h, m or s, or d for days
+    --from-now sets TIME to DURATION after the real current time, wherever TIME was, and --from-time moves it from
+    TIME. config relative-anchor picks which one is used without either`,
+	"rewind": `usage: rewind <DURATION> [--from-now|--from-time]
+    move TIME back by DURATION, e.g. rewind 90m or rewind 1d
+    --from-now and --from-time are as for advance`,
+	"resettime": `usage: resettime
+    set TIME back to the real current time`,
+	"tz": `usage: tz [ZONE]
+    show or change the timezone TIME and forecasts are shown in, e.g. tz UTC or tz America/New_York
+    LOCATION's own timezone is used until then
+  or:    tz reset
+    go back to LOCATION's timezone`,
+	"loc": `usage: loc [--detailed]
+    print LOCATION. --detailed adds its coordinates and timezone, and the one set with tz`,
+	"setloc": `usage: setloc <CITY> <REGION> <COUNTRY>
+    any value can be * to leave it alone, e.g. setloc Denver Colorado *
+    COUNTRY can be its name or its two or three letter code, e.g. US, USA or United States
+    LOCATION stays the same if no such place is found, or if CITY could be places in different regions
+  or:    setloc [city=<CITY>] [region=<REGION>] [country=<COUNTRY>]
+    change only the named values, e.g. setloc country=Canada region=Ontario or setloc city=New York
+  or:    setloc --zip <CODE> [COUNTRY]
+    go to the place with that postal code, in COUNTRY or else LOCATION's country, e.g. setloc --zip 94103 US
+  or:    setloc --pick <N>
+    when setloc found more than one place with that name, go to the Nth one it listed
+  or:    setloc --current
+    look up LOCATION from your IP address again
+  or:    setloc
+    go back to the location weth started with`,
+	"recent": `usage: recent [N]
+    list the last locations setloc went to, most recent first, or go back to the Nth of them`,
+	"snapshot": `usage: snapshot <FILE>
+    write TIME, LOCATION, the units, clock and language, the provider, and the last responses weth has had from it
+    to FILE as JSON, so someone else can see what you saw. API keys and your IP address are left out
+    load-snapshot FILE puts it all back`,
+	"load-snapshot": `usage: load-snapshot <FILE>
+    set TIME, LOCATION and the settings from a snapshot, and answer requests with its responses instead of the network
+    commands the snapshot has responses for show what they showed when it was made
+    its units are only for this session, until units picks others, and nothing loaded is saved to the config`,
+	"units": `usage: units [metric|imperial|both]
+    show or change the units weather is reported in
+  or:    units --for-location <metric|imperial|both|off>
+    use these units whenever LOCATION is this place, whatever the units are elsewhere. off goes back to them`,
+	"lang": `usage: lang [CODE]
+    show the language and the available ones, or switch to CODE, e.g. lang es
+    WETH_LANG sets the language weth starts in`,
+	"provider": `usage: provider [NAME]
+    show the weather provider and the available ones, or switch to NAME`,
+	"offline": `usage: offline [on|off]
+    show or change whether weather data is synthetic instead of fetched`,
+	"refresh": `usage: refresh
+    clear cached weather and fetch now again`,
+	"config": `usage: config [SETTING [VALUE]]
+    list the settings, show one, or change it, e.g. config hours-default 6
+    changes are saved to the config file`,
+	"color": `usage: color <on|off>
+    turn colored output on or off`,
+	"colors": `usage: colors [reset | [metric|imperial] BLUE CYAN GREEN YELLOW]
+    show the temperatures where colored output goes from blue to cyan, green, yellow and red, or change them
+    give the four temperatures each color ends at, from coldest to hottest, e.g. colors 0 10 20 30. They're in the
+    units temperatures are shown in, unless metric (°C) or imperial (°F) comes first
+    a temperature exactly at one of them gets the warmer color
+    colors reset goes back to the defaults, 0 10 20 30 in °C and 32 50 68 86 in °F`,
+	"raw": `usage: raw <now|hours|days> [COUNT] [--cached]
+    print the response the provider gave for now, or COUNT hours or days from TIME, as JSON
+    it's fetched again unless --cached is given, which reuses the response from an earlier raw, not from now, hours or
+    days. Not every provider can show its responses`,
+	"diagnose": `usage: diagnose
+    check each service weth uses, reporting OK or FAILED, how long it took, and the error if there was one
+    the weather check skips the cache, so it always makes a request`,
+	"log": `usage: log
+    list the weather, TIME and LOCATION commands run this session, when they ran, and the first line of what they
+    printed. Only the last 200 are kept, and none are kept after weth exits
+  or:    log clear
+    empty the log
+  or:    log save <FILE>
+    write the log to FILE`,
+	"explain": `usage: explain
+    say how LOCATION was found, like from your IP address or with setloc, with its coordinates and timezone, how TIME
+    relates to the real current time, and where the provider and units in use come from
+    diagnose checks the services weth uses instead`,
+	"history": `usage: history [COUNT]
+    list the last COUNT commands, or all of them. !N runs entry N again
+    !! is the last command, e.g. !! --csv runs it again with --csv
+    with config empty-line repeat, Enter on an empty line runs the last command again`,
+	"alias": `usage: alias <NAME> "<COMMAND>"
+    define NAME as a shortcut for COMMAND, e.g. alias week "days 7"
+  or:    alias
+    list aliases`,
+	"unalias": `usage: unalias <NAME>
+    remove an alias`,
+	"help": `usage: help [COMMAND]
+    list commands, or show the detailed usage of COMMAND`,
+	"exit": `usage: exit
+    leave weth`,
+}
+
+// formatUsage indents usage text for printing
+func formatUsage(text string) string {
+	return "  " + strings.ReplaceAll(text, "\n", "\n  ")
+}
+
+// usageNotes add to a command's usage what depends on how weth is set up, like the provider's limits
+var usageNotes = map[string]func() string{
+	"hours": func() string {
+		return fmt.Sprintf(tr("%s forecasts up to %s ahead"), provider.Name(), plural(maxForecastHours(), "hour"))
+	},
+	"days": func() string {
+		return fmt.Sprintf(tr("%s forecasts up to %s ahead"), provider.Name(), plural(maxForecastDays(), "day"))
+	},
+	"settime": anchorNote,
+	"advance": anchorNote,
+	"rewind":  anchorNote,
+	"diff":    anchorNote,
+}
+
+// commandUsage is the formatted usage of command, with any of its usageNotes
+func commandUsage(command Command) string {
+
+	text := command.Usage
+	if note, ok := usageNotes[command.Name]; ok {
+		text += "\n    " + note()
+	}
+	return formatUsage(text)
+}
+
+// usage is the formatted usage of one of weth's own commands
+func usage(command string) string {
+	return formatUsage(usageStrings[command])
+}
+
+func (r *REPL) printHelp(args []string) (string, error) {
+
+	commands := append(r.Commands(), Command{Name: "exit", Help: "leave weth", Usage: usageStrings["exit"]})
+
+	if len(args) > 0 {
+		for _, command := range commands {
+			if command.Name == args[0] {
+				return commandUsage(command), nil
+			}
+		}
+		return "", errors.New("no command named " + args[0])
+	}
+
+	width := 0
+	for _, command := range commands {
+		width = max(width, len(command.Name))
+	}
+
+	lines := []string{helpIntro, ""}
+	for _, command := range commands {
+		lines = append(lines, fmt.Sprintf("%-*s  %s", width, command.Name, command.Help))
+	}
+
+	return strings.Join(lines, "\n  "), nil
+}
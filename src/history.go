@@ -0,0 +1,173 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// commandHistory records the lines entered into the REPL, oldest first. It satisfies term.History, so the terminal
+// reader can walk through it with the arrow keys.
+type commandHistory struct {
+	entries []string
+	limit   int
+}
+
+var history commandHistory
+
+func (h *commandHistory) Add(entry string) {
+
+	if strings.TrimSpace(entry) == "" {
+		return
+	}
+
+	if len(h.entries) > 0 && h.entries[len(h.entries)-1] == entry {
+		return
+	}
+
+	h.entries = append(h.entries, entry)
+
+	if h.limit > 0 && len(h.entries) > h.limit {
+		h.entries = h.entries[len(h.entries)-h.limit:]
+	}
+}
+
+func (h *commandHistory) Len() int {
+	return len(h.entries)
+}
+
+// At indexes from the most recent entry, as term.History expects.
+func (h *commandHistory) At(idx int) string {
+	return h.entries[len(h.entries)-1-idx]
+}
+
+// recallOnly lets the terminal read the history without adding to it. The REPL loop adds lines itself once any
+// '!N' references have been expanded.
+type recallOnly struct {
+	*commandHistory
+}
+
+func (recallOnly) Add(string) {}
+
+func historyPath() (string, error) {
+	dir, err := configDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, "history"), nil
+}
+
+func loadHistory() {
+
+	history.limit = config.HistorySize
+
+	path, err := historyPath()
+	if err != nil {
+		return
+	}
+
+	body, err := os.ReadFile(path)
+	if err != nil {
+		return
+	}
+
+	for _, line := range strings.Split(string(body), "\n") {
+		history.Add(line)
+	}
+}
+
+func saveHistory() {
+
+	path, err := historyPath()
+	if err != nil {
+		return
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		printWarning(fmt.Sprintf("could not save history: %v", err))
+		return
+	}
+
+	body := strings.Join(history.entries, "\n") + "\n"
+
+	if err := os.WriteFile(path, []byte(body), 0o600); err != nil {
+		printWarning(fmt.Sprintf("could not save history: %v", err))
+	}
+}
+
+// lastEntry is the most recent line in the history, or empty when there isn't one
+func lastEntry() string {
+	if len(history.entries) == 0 {
+		return ""
+	}
+	return history.entries[len(history.entries)-1]
+}
+
+// refersToHistory reports whether line needs expandHistory: it's '!N', or has a '!!' in it
+func refersToHistory(line string) bool {
+	return strings.HasPrefix(line, "!") || slices.Contains(strings.Fields(line), "!!")
+}
+
+// expandHistory replaces a '!N' line with the Nth entry printed by the history command, and each '!!' in a line with
+// the last entry, e.g. '!! --csv' runs the last command again with --csv.
+func expandHistory(line string) (string, error) {
+
+	words := strings.Fields(line)
+
+	if !slices.Contains(words, "!!") {
+
+		n, err := strconv.Atoi(strings.TrimPrefix(line, "!"))
+		if err != nil {
+			return "", errors.New("expected a history entry number, got " + line)
+		}
+
+		if n < 1 || n > len(history.entries) {
+			return "", fmt.Errorf("no history entry %d", n)
+		}
+
+		return history.entries[n-1], nil
+	}
+
+	last := lastEntry()
+	if last == "" {
+		return "", errors.New("no previous command for !!")
+	}
+
+	// lines are expanded before they're added, so this only happens with a history file written by hand
+	if refersToHistory(last) {
+		return "", errors.New("the previous command refers to the history itself, so !! can't repeat it")
+	}
+
+	for i, word := range words {
+		if word == "!!" {
+			words[i] = last
+		}
+	}
+
+	return strings.Join(words, " "), nil
+}
+
+func printHistory(args []string) (string, error) {
+
+	count := len(history.entries)
+
+	if len(args) > 0 {
+		n, err := strconv.Atoi(args[0])
+		if err != nil || n < 0 {
+			return "", errUsage("history")
+		}
+		count = min(n, count)
+	}
+
+	var lines []string
+	for i := len(history.entries) - count; i < len(history.entries); i++ {
+		lines = append(lines, fmt.Sprintf("%4d  %s", i+1, history.entries[i]))
+	}
+
+	return strings.Join(lines, "\n  "), nil
+}
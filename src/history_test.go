@@ -0,0 +1,27 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestPrintHistory(t *testing.T) {
+
+	saved := slices.Clone(history.entries)
+	t.Cleanup(func() { history.entries = saved })
+	history.entries = []string{"now", "hours 3", "setloc Paris"}
+
+	// indented like other output after its first line, which printOutput indents
+	want := "   1  now\n     2  hours 3\n     3  setloc Paris"
+	if output, err := printHistory(nil); err != nil || output != want {
+		t.Errorf("history = %q, %v, want %q", output, err, want)
+	}
+
+	if output, _ := printHistory([]string{"2"}); output != "   2  hours 3\n     3  setloc Paris" {
+		t.Errorf("history 2 = %q", output)
+	}
+
+	if _, err := printHistory([]string{"-1"}); err == nil {
+		t.Error("history -1 succeeded")
+	}
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// the most of a refused request's response shown in its error
+const maxErrorSnippet = 200
+
+// httpStatusError is a response that came back with a status other than 2xx, so its body isn't the data asked for.
+// A 429 is errRateLimited as far as errors.Is goes, the same as when weth's own rate limit turns a request away.
+type httpStatusError struct {
+	Host   string
+	Status int
+	Body   []byte
+}
+
+func newHTTPStatusError(address string, status int, body []byte) httpStatusError {
+	host := address
+	if u, err := url.Parse(address); err == nil && u.Host != "" {
+		host = u.Host
+	}
+	return httpStatusError{Host: host, Status: status, Body: body}
+}
+
+func (e httpStatusError) Error() string {
+
+	status := fmt.Sprintf("%d %s", e.Status, http.StatusText(e.Status))
+
+	switch {
+	case e.Status == http.StatusTooManyRequests:
+		return fmt.Sprintf("%s is rate limiting requests (%s), try again shortly", e.Host, status)
+	case e.Status >= 500:
+		return fmt.Sprintf("%s had a problem answering (%s)", e.Host, status)
+	}
+
+	if detail := errorDetail(e.Body); detail != "" {
+		return fmt.Sprintf("%s refused the request (%s): %s", e.Host, status, detail)
+	}
+	return fmt.Sprintf("%s refused the request (%s)", e.Host, status)
+}
+
+func (e httpStatusError) Is(target error) bool {
+	return target == errRateLimited && e.Status == http.StatusTooManyRequests
+}
+
+// errorDetail is what a refusal says went wrong: the reason, message or detail field APIs put it in when the body is
+// JSON, or else the start of the body.
+func errorDetail(body []byte) string {
+
+	var fields map[string]any
+	if json.Unmarshal(body, &fields) == nil {
+		for _, key := range []string{"reason", "message", "detail"} {
+			if text, ok := fields[key].(string); ok && text != "" {
+				return text
+			}
+		}
+	}
+
+	snippet := strings.Join(strings.Fields(string(body)), " ")
+	if len(snippet) > maxErrorSnippet {
+		snippet = strings.ToValidUTF8(snippet[:maxErrorSnippet], "") + "…"
+	}
+	return snippet
+}
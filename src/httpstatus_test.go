@@ -0,0 +1,85 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestRefusedStatuses(t *testing.T) {
+
+	tests := []struct {
+		status   int
+		body     string
+		attempts int
+		message  string
+		limited  bool
+	}{
+		{http.StatusTooManyRequests, "slow down", maxRequestAttempts, "api.open-meteo.com is rate limiting requests (429 Too Many Requests), try again shortly", true},
+		{http.StatusInternalServerError, "oops", maxRequestAttempts, "api.open-meteo.com had a problem answering (500 Internal Server Error)", false},
+		{http.StatusNotFound, `{"error": true, "reason": "no such endpoint"}`, 1, "api.open-meteo.com refused the request (404 Not Found): no such endpoint", false},
+		{http.StatusNotFound, "<html>\n  <body>Not Found</body>\n</html>", 1, "api.open-meteo.com refused the request (404 Not Found): <html> <body>Not Found</body> </html>", false},
+	}
+
+	for _, test := range tests {
+		t.Run(http.StatusText(test.status), func(t *testing.T) {
+			useTestState(t)
+			fakeRetrySleep(t)
+			doer := respondWith(t, test.status, test.body)
+
+			_, err := getBody("https://api.open-meteo.com/v1/forecast?latitude=1&longitude=2")
+			if err == nil {
+				t.Fatal("no error")
+			}
+
+			if err.Error() != test.message {
+				t.Errorf("error %q, want %q", err, test.message)
+			}
+			if errors.Is(err, errRateLimited) != test.limited {
+				t.Errorf("errors.Is(err, errRateLimited) = %v, want %v", !test.limited, test.limited)
+			}
+			if len(doer.requests()) != test.attempts {
+				t.Errorf("sent %d requests, want %d", len(doer.requests()), test.attempts)
+			}
+		})
+	}
+}
+
+func TestRateLimitedThroughCommands(t *testing.T) {
+	useTestState(t)
+	fakeRetrySleep(t)
+	offline, provider = false, withCache(newOpenMeteo(""))
+	respondWith(t, http.StatusTooManyRequests, "")
+
+	commands := []struct {
+		name string
+		run  func([]string) (string, error)
+		args []string
+	}{
+		{"now", nowWeather, nil},
+		{"hours", hoursForecast, []string{"3"}},
+		{"days", daysForecast, []string{"3"}},
+	}
+
+	for _, command := range commands {
+		if _, err := command.run(command.args); !errors.Is(err, errRateLimited) {
+			t.Errorf("%s: err = %v, want errRateLimited", command.name, err)
+		}
+	}
+}
+
+func TestErrorDetailTruncated(t *testing.T) {
+
+	// with the a in front, the cut at maxErrorSnippet bytes falls partway through an é
+	detail := errorDetail([]byte("a" + strings.Repeat("é", maxErrorSnippet)))
+
+	if !strings.HasSuffix(detail, "…") {
+		t.Fatalf("errorDetail = %q, want it cut short", detail)
+	}
+
+	// the half of it that's left is dropped rather than shown as a broken character
+	if trimmed := strings.TrimSuffix(detail, "…"); len(trimmed) > maxErrorSnippet || strings.ContainsRune(trimmed, '�') {
+		t.Errorf("kept %d bytes: %q", len(trimmed), trimmed)
+	}
+}
@@ -0,0 +1,339 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"slices"
+	"strings"
+	"time"
+)
+
+/*
+	User-facing text is written in English and looked up in a catalog for the current language right before it's
+	shown, falling back to the English when there's no translation. Month and weekday names have a table of their own
+	per language, since they're needed for input too.
+*/
+
+// language is the code of the language output is shown in. WETH_LANG takes precedence over the config file.
+var language = "en"
+
+var monthNames = map[string][12]string{
+	"en": {"January", "February", "March", "April", "May", "June", "July", "August", "September", "October", "November", "December"},
+	"es": {"enero", "febrero", "marzo", "abril", "mayo", "junio", "julio", "agosto", "septiembre", "octubre", "noviembre", "diciembre"},
+}
+
+var monthAbbreviations = map[string][12]string{
+	"en": {"Jan", "Feb", "Mar", "Apr", "May", "Jun", "Jul", "Aug", "Sep", "Oct", "Nov", "Dec"},
+	"es": {"ene", "feb", "mar", "abr", "may", "jun", "jul", "ago", "sep", "oct", "nov", "dic"},
+}
+
+// weekdays start on Sunday, like time.Weekday
+var weekdayAbbreviations = map[string][7]string{
+	"en": {"Sun", "Mon", "Tue", "Wed", "Thu", "Fri", "Sat"},
+	"es": {"dom", "lun", "mar", "mié", "jue", "vie", "sáb"},
+}
+
+// catalogs maps a language code to translations of the English text shown to users
+var catalogs = map[string]map[string]string{
+	"es": {
+		// weather conditions
+		"Clear":              "Despejado",
+		"Mostly Clear":       "Mayormente Despejado",
+		"Partly Cloudy":      "Parcialmente Nublado",
+		"Overcast":           "Cubierto",
+		"Fog":                "Niebla",
+		"Freezing Fog":       "Niebla Helada",
+		"Light Drizzle":      "Llovizna Ligera",
+		"Drizzle":            "Llovizna",
+		"Heavy Drizzle":      "Llovizna Intensa",
+		"Freezing Drizzle":   "Llovizna Helada",
+		"Light Rain":         "Lluvia Ligera",
+		"Rain":               "Lluvia",
+		"Heavy Rain":         "Lluvia Intensa",
+		"Freezing Rain":      "Lluvia Helada",
+		"Light Snow":         "Nieve Ligera",
+		"Snow":               "Nieve",
+		"Heavy Snow":         "Nieve Intensa",
+		"Snow Grains":        "Granos de Nieve",
+		"Light Showers":      "Chubascos Ligeros",
+		"Showers":            "Chubascos",
+		"Heavy Showers":      "Chubascos Intensos",
+		"Snow Showers":       "Chubascos de Nieve",
+		"Heavy Snow Showers": "Chubascos de Nieve Intensos",
+		"Thunderstorm":       "Tormenta",
+
+		// weather descriptions
+		"Clear skies":                   "Cielo despejado",
+		"Mostly clear skies":            "Cielo mayormente despejado",
+		"Partly cloudy":                 "Parcialmente nublado",
+		"Overcast skies":                "Cielo cubierto",
+		"Foggy":                         "Niebla",
+		"Freezing fog":                  "Niebla helada",
+		"Light drizzle":                 "Llovizna ligera",
+		"Moderate drizzle":              "Llovizna moderada",
+		"Dense drizzle":                 "Llovizna densa",
+		"Light freezing drizzle":        "Llovizna helada ligera",
+		"Dense freezing drizzle":        "Llovizna helada densa",
+		"Light rain":                    "Lluvia ligera",
+		"Moderate rain":                 "Lluvia moderada",
+		"Heavy rain":                    "Lluvia intensa",
+		"Light freezing rain":           "Lluvia helada ligera",
+		"Heavy freezing rain":           "Lluvia helada intensa",
+		"Light snowfall":                "Nevada ligera",
+		"Moderate snowfall":             "Nevada moderada",
+		"Heavy snowfall":                "Nevada intensa",
+		"Snow grains":                   "Granos de nieve",
+		"Light rain showers":            "Chubascos ligeros",
+		"Moderate rain showers":         "Chubascos moderados",
+		"Violent rain showers":          "Chubascos violentos",
+		"Light snow showers":            "Chubascos de nieve ligeros",
+		"Heavy snow showers":            "Chubascos de nieve intensos",
+		"Thunderstorms":                 "Tormentas",
+		"Thunderstorms with light hail": "Tormentas con granizo ligero",
+		"Thunderstorms with heavy hail": "Tormentas con granizo intenso",
+		"with":                          "con",
+		"a light breeze":                "brisa ligera",
+		"a fresh breeze":                "brisa fresca",
+		"strong winds":                  "viento fuerte",
+		"gale-force winds":              "viento huracanado",
+
+		// now
+		"Conditions":  "Condiciones",
+		"Temperature": "Temperatura",
+		"Feels like":  "Sensación",
+		"Wind":        "Viento",
+		"Humidity":    "Humedad",
+		"Dew point":   "Punto de rocío",
+		"Dry":         "Seco",
+		"Comfortable": "Agradable",
+		"Humid":       "Húmedo",
+		"Oppressive":  "Bochornoso",
+		"Pressure":    "Presión",
+		"UV index":    "Índice UV",
+		"Low":         "Bajo",
+		"Moderate":    "Moderado",
+		"High":        "Alto",
+		"Very High":   "Muy Alto",
+		"Extreme":     "Extremo",
+		"feels like":  "sensación",
+		"wind":        "viento",
+		"gusts":       "rachas",
+		"high":        "máxima",
+		"low":         "mínima",
+		"in":          "en",
+
+		// forecast
+		"Day":     "Día",
+		"Weather": "Tiempo",
+
+		// daylight saving
+		"clocks forward": "adelantar reloj",
+		"clocks back":    "atrasar reloj",
+
+		// time and location
+		"(now)":                          "(ahora)",
+		"(today)":                        "(hoy)",
+		"(in %s)":                        "(en %s)",
+		"(%s ago)":                       "(hace %s)",
+		"minute":                         "minuto",
+		"hour":                           "hora",
+		"day":                            "día",
+		"Location":                       "Ubicación",
+		"Coordinates":                    "Coordenadas",
+		"Timezone":                       "Zona horaria",
+		"Times shown in":                 "Horas mostradas en",
+		"set with tz":                    "cambiada con tz",
+		"not set":                        "sin definir",
+		"%s, from %s":                    "%s, desde %s",
+		"showing %s (nearest to %s)":     "mostrando %s (la más cercana a %s)",
+		"showing %s (the hour %s is in)": "mostrando %s (la hora de %s)",
+
+		// moon phases
+		"New Moon":        "Luna Nueva",
+		"Waxing Crescent": "Creciente",
+		"First Quarter":   "Cuarto Creciente",
+		"Waxing Gibbous":  "Gibosa Creciente",
+		"Full Moon":       "Luna Llena",
+		"Waning Gibbous":  "Gibosa Menguante",
+		"Last Quarter":    "Cuarto Menguante",
+		"Waning Crescent": "Menguante",
+
+		// alerts and air quality
+		"No active alerts.":              "No hay alertas activas.",
+		"Dominant pollutant":             "Contaminante principal",
+		"Good":                           "Buena",
+		"Unhealthy for Sensitive Groups": "Dañina para Grupos Sensibles",
+		"Unhealthy":                      "Dañina",
+		"Very Unhealthy":                 "Muy Dañina",
+		"Hazardous":                      "Peligrosa",
+
+		// errors
+		"%s: command not found": "%s: comando no encontrado",
+		"no coordinates known for this location. Try setting it again with setloc": "no se conocen las coordenadas de esta ubicación. Prueba a definirla otra vez con setloc",
+		"could not fetch weather: ":      "no se pudo obtener el tiempo: ",
+		"no weather data available for ": "no hay datos del tiempo para ",
+
+		// confirmations
+		"units set to ":                            "unidades cambiadas a ",
+		"language set to ":                         "idioma cambiado a ",
+		"times are shown in ":                      "las horas se muestran en ",
+		"times are shown in LOCATION's timezone, ": "las horas se muestran en la zona horaria de LOCATION, ",
+		"LOCATION's own":                           "la de LOCATION",
+
+		"windy": "ventoso",
+
+		"no recent locations yet. setloc adds them": "todavía no hay lugares recientes. setloc los añade",
+
+		"%s forecasts at most %s":     "%s pronostica como máximo %s",
+		"%s forecasts up to %s ahead": "%s pronostica hasta %s",
+
+		"snapshot written to ":      "instantánea escrita en ",
+		"response":                  "respuesta",
+		"loaded the snapshot from ": "instantánea cargada del ",
+		"requests are answered from the snapshot until weth is restarted": "las peticiones se responden con la instantánea hasta que weth se reinicie",
+
+		"line %d, %s: %v":            "línea %d, %s: %v",
+		"every location failed: ":    "todos los lugares fallaron: ",
+		"%d of %d locations failed:": "%d de %d lugares fallaron:",
+		"no such place":              "no existe ese lugar",
+		"about %s left":              "quedan unos %s",
+
+		"relative values count from the real current time, or from TIME with --from-time": "los valores relativos cuentan desde la hora actual real, o desde TIME con --from-time",
+		"relative values count from TIME, or from the real current time with --from-now":  "los valores relativos cuentan desde TIME, o desde la hora actual real con --from-now",
+
+		"usual":                                  "habitual",
+		"warmer":                                 "más cálido",
+		"cooler":                                 "más fresco",
+		"as usual":                               "como siempre",
+		"just right":                             "en su punto",
+		"showing the forecast without normals: ": "se muestra el pronóstico sin valores habituales: ",
+		"%s doesn't know the usual weather":      "%s no conoce el tiempo habitual",
+
+		"the location weth started with, ":                     "el lugar con el que weth empezó, ",
+		"looked up from your IP address":                       "buscado a partir de tu dirección IP",
+		"looked up from your IP address on an earlier run":     "buscado a partir de tu dirección IP en una ejecución anterior",
+		"looked up from your IP address with setloc --current": "buscado a partir de tu dirección IP con setloc --current",
+		"set with setloc":                  "fijado con setloc",
+		"picked with setloc --pick":        "elegido con setloc --pick",
+		"found from the postal code %s":    "encontrado por el código postal %s",
+		"gone back to with recent":         "recuperado con recent",
+		"loaded from a snapshot":           "cargado de una instantánea",
+		"given with --location":            "dado con --location",
+		"offline mode's stand-in location": "el lugar de reemplazo del modo sin conexión",
+		"set by WETH_DEFAULT_LOCATION":     "fijado por WETH_DEFAULT_LOCATION",
+		"at %s, where the timezone is %s":  "en %s, donde la zona horaria es %s",
+		"no coordinates known, so there's no weather for it. Try setting it again with setloc": "no se conocen sus coordenadas, así que no hay tiempo para él. Prueba a fijarlo de nuevo con setloc",
+		"Time":                  "Hora",
+		"the real current time": "la hora actual real",
+		"moved from the real current time with settime, advance or rewind, %s": "movida desde la hora actual real con settime, advance o rewind, %s",
+		"shown in %s, set with tz":         "mostrada en %s, fijada con tz",
+		"shown on LOCATION's clock, %s":    "mostrada con el reloj de LOCATION, %s",
+		"Provider":                         "Proveedor",
+		"Units":                            "Unidades",
+		"answering from a loaded snapshot": "respondiendo con una instantánea cargada",
+		"since offline mode is on":         "porque el modo sin conexión está activado",
+		"falling back to %s":               "con %s de respaldo",
+		"saved for this location with units --for-location": "guardadas para este lugar con units --for-location",
+		"chosen with units":                               "elegidas con units",
+		"from the loaded snapshot":                        "de la instantánea cargada",
+		"the usual units in %s, where your IP address is": "las habituales en %s, donde está tu dirección IP",
+		"the default, until units picks them":             "las predeterminadas, hasta que units las elija",
+
+		"no change":     "sin cambio",
+		"Precipitation": "Precipitación",
+		"%s is further ahead than %s forecasts, which is %s":      "%s está más adelante de lo que %s pronostica, que es %s",
+		"%s is further back than %s has weather for, which is %s": "%s está más atrás de lo que %s tiene del tiempo, que es %s",
+
+		"below": "por debajo de",
+		"above": "por encima de",
+		"the temperature doesn't go %s %s before %s": "la temperatura no está %s %s antes de %s",
+		"the temperature is already %s %s at %s: %s": "la temperatura ya está %s %s a las %s: %s",
+		"the temperature goes %s %s at %s %s: %s":    "la temperatura pasa %s %s a las %s %s: %s",
+
+		"nothing has been looked up yet this session": "todavía no se ha consultado nada en esta sesión",
+		"log cleared":   "registro borrado",
+		"log saved to ": "registro guardado en ",
+		"command":       "comando",
+		"failed: ":      "falló: ",
+		"wrong usage":   "uso incorrecto",
+
+		// past weather
+		"historical": "histórico",
+		"%s has no past weather, only forecasts. resettime goes back to now": "%s no tiene el tiempo pasado, solo pronósticos. resettime vuelve a ahora",
+
+		// units saved for a location
+		"for":                               "para",
+		"elsewhere":                         "en otros lugares",
+		"but %s still uses %s":              "pero %s sigue usando %s",
+		"using %s, saved for this location": "usando %s, guardado para este lugar",
+	},
+}
+
+// tr translates English text shown to users into the current language.
+func tr(text string) string {
+	if translated, ok := catalogs[language][text]; ok {
+		return translated
+	}
+	return text
+}
+
+func languageNames() []string {
+	var names []string
+	for name := range monthNames {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+func initLanguage() {
+
+	language = config.Language
+	if env := os.Getenv("WETH_LANG"); env != "" {
+		language = strings.ToLower(env)
+	}
+
+	if _, ok := monthNames[language]; !ok {
+		language = "en"
+	}
+}
+
+func monthName(month time.Month) string {
+	return monthNames[language][month-1]
+}
+
+// lookupMonth finds a month by its name or abbreviation, in English or the current language.
+func lookupMonth(name string) (int, bool) {
+
+	name = strings.ToLower(name)
+
+	for _, code := range []string{language, "en"} {
+		for i := range 12 {
+			if name == strings.ToLower(monthNames[code][i]) || name == strings.ToLower(monthAbbreviations[code][i]) {
+				return i + 1, true
+			}
+		}
+	}
+
+	return 0, false
+}
+
+func setLanguage(args []string) (string, error) {
+
+	if len(args) == 0 {
+		return "language: " + language + "\n  available: " + strings.Join(languageNames(), ", "), nil
+	}
+
+	if _, ok := monthNames[args[0]]; !ok {
+		return "", errors.New("unknown language " + args[0] + ". Available: " + strings.Join(languageNames(), ", "))
+	}
+
+	language = args[0]
+	config.Language = args[0]
+
+	if err := saveConfig(); err != nil {
+		return "  " + tr("language set to ") + args[0] + ", but could not be saved: " + err.Error(), nil
+	}
+
+	return "  " + tr("language set to ") + args[0], nil
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"time"
+)
+
+/*
+	With --json, the weather commands print their data as JSON instead of text, for other programs to read. Values
+	are in metric whatever the units setting is, and times are RFC 3339.
+*/
+
+var jsonOutput = false
+
+// forecastJSON is hours and days output, with the location and time it's for
+type forecastJSON struct {
+	Location Location          `json:"location"`
+	Time     time.Time         `json:"time"`
+	Hours    []Conditions      `json:"hours,omitempty"`
+	Days     []DailyConditions `json:"days,omitempty"`
+	Normals  []DailyNormal     `json:"normals,omitempty"`
+}
+
+// writeJSON prints value to stdout directly, so it isn't indented or colored like the usual output.
+func writeJSON(value any) (string, error) {
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+
+	if err := encoder.Encode(value); err != nil {
+		return "", errors.New("could not write JSON: " + err.Error())
+	}
+
+	return "", nil
+}
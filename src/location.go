@@ -0,0 +1,151 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+/*
+	Looking up the location from the IP address takes two round trips, and ip-api.com rate limits them. The result is
+	saved to ~/.config/weth/location.json and reused until it's older than config.LocationMaxAgeHours.
+*/
+
+type cachedLocation struct {
+	Fetched  time.Time `json:"fetched"`
+	Location Location  `json:"location"`
+}
+
+func locationCachePath() (string, error) {
+	dir, err := configDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, "location.json"), nil
+}
+
+// loadCachedLocation sets defaultLocation from the cache file, and reports whether it was fresh enough to use.
+func loadCachedLocation() bool {
+
+	path, err := locationCachePath()
+	if err != nil {
+		return false
+	}
+
+	body, err := os.ReadFile(path)
+	if err != nil {
+		return false
+	}
+
+	var cache cachedLocation
+	if err := json.Unmarshal(body, &cache); err != nil {
+		return false
+	}
+
+	maxAge := time.Duration(config.LocationMaxAgeHours) * time.Hour
+	if time.Since(cache.Fetched) > maxAge {
+		return false
+	}
+
+	defaultLocation = cache.Location
+	return true
+}
+
+// saveCachedLocation is best effort. Failing to save only means looking the location up again next time.
+func saveCachedLocation() {
+
+	path, err := locationCachePath()
+	if err != nil {
+		return
+	}
+
+	body, err := json.Marshal(cachedLocation{Fetched: time.Now(), Location: defaultLocation.coarsened()})
+	if err != nil {
+		return
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return
+	}
+
+	os.WriteFile(path, body, 0o600)
+}
+
+// parseLocation reads a location written as "City,Region,Country". Region and country may be left off.
+func parseLocation(value string) (Location, error) {
+
+	var loc Location
+
+	parts := strings.Split(value, ",")
+	for i := range parts {
+		parts[i] = strings.TrimSpace(parts[i])
+	}
+
+	if len(parts) > 3 || parts[0] == "" {
+		return loc, errors.New("expected \"City,Region,Country\"")
+	}
+
+	loc.City = parts[0]
+	if len(parts) > 1 {
+		loc.Region = parts[1]
+	}
+	if len(parts) > 2 {
+		loc.Country = parts[2]
+	}
+
+	return loc, nil
+}
+
+// geocodeParsed geocodes a location from parseLocation. Two parts, like "Kyoto,Japan" or "Denver,Colorado", could
+// be a city and a region or a city and a country, so the country is tried first since it has to match exactly.
+func geocodeParsed(loc Location) (Location, error) {
+
+	if loc.Region != "" && loc.Country == "" {
+		if resolved, err := geocode(Location{City: loc.City, Country: loc.Region}); err == nil {
+			return resolved, nil
+		}
+	}
+
+	return geocode(loc)
+}
+
+// locationFromEnv reads WETH_DEFAULT_LOCATION, written as "City,Region,Country". Region and country may be left
+// off. ok is false when the variable isn't set or can't be used, so the caller falls back to the IP lookup.
+func locationFromEnv() (loc Location, ok bool) {
+
+	value, set := os.LookupEnv("WETH_DEFAULT_LOCATION")
+	if !set {
+		return loc, false
+	}
+
+	loc, err := parseLocation(value)
+	if err != nil {
+		printWarning(fmt.Sprintf("ignoring WETH_DEFAULT_LOCATION=%q, %v", value, err))
+		return loc, false
+	}
+
+	resolved, err := geocodeParsed(loc)
+	if err != nil {
+		printWarning(fmt.Sprintf("could not find coordinates for WETH_DEFAULT_LOCATION, so looking it up from your IP address instead: %v", err))
+		return loc, false
+	}
+
+	return resolved, true
+}
+
+// nonEmpty drops the empty values, so a location missing its region doesn't print as "Denver, , US"
+func nonEmpty(values ...string) []string {
+
+	var kept []string
+	for _, value := range values {
+		if value != "" {
+			kept = append(kept, value)
+		}
+	}
+	return kept
+}
@@ -1,17 +1,19 @@
 package main
 
 import (
-	"bufio"
-	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
-	"io"
 	"log"
-	"net/http"
+	"math"
 	"os"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
 	"unicode/utf8"
+
+	"golang.org/x/term"
 )
 
 /*
@@ -36,57 +38,104 @@ import (
 */
 
 var internalTime time.Time
-var validMonthCodes = map[string]int{"january": 1, "february": 2, "march": 3, "april": 4, "may": 5, "june": 6, "july": 7, "august": 8, "september": 9, "october": 10, "november": 11, "december": 12, "jan": 1, "feb": 2, "mar": 3, "apr": 4, "jun": 6, "jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12}
-var codesToMonth = map[int]string{1: "January", 2: "February", 3: "March", 4: "April", 5: "May", 6: "June", 7: "July", 8: "August", 9: "September", 10: "October", 11: "November", 12: "December"}
 
 var militaryTime bool
 
-var usageStrings = map[string]string{
-	"setTime": "  usage: settime <HOUR> <DAY> <MONTH> <YEAR>", // TODO: make a better usage message than this nonsense.
-}
+// the range of years settime accepts
+const minYear, maxYear = 1900, 2200
 
 type Location struct {
-	Country  string `json:"country"`
-	Region   string `json:"region"`
-	City     string `json:"city"`
-	Timezone string `json:"timezone"`
+	Country  string  `json:"country"`
+	Region   string  `json:"region"`
+	City     string  `json:"city"`
+	Timezone string  `json:"timezone"`
+	Lat      float64 `json:"lat"`
+	Lon      float64 `json:"lon"`
+
+	// ISO 3166 alpha-2, when the country is one lookupCountry knows
+	CountryCode string `json:"country_code,omitempty"`
 }
 
-var internalLocation Location
-var defaultLocation Location
+// locationZone is the timezone of internalLocation, or the local timezone when it isn't known.
+func locationZone() *time.Location {
 
-func printTime() string {
-	hour := ""
+	if internalLocation.Timezone == "" {
+		return time.Local
+	}
+
+	zone, err := time.LoadLocation(internalLocation.Timezone)
+	if err != nil {
+		return time.Local
+	}
 
-	if !militaryTime {
+	return zone
+}
 
-		if internalTime.Hour() > 12 {
-			hour = strconv.Itoa(internalTime.Hour()%12) + "PM"
+// a location that was never geocoded has no coordinates. (0, 0) is in the middle of the ocean anyway.
+func (l Location) hasCoordinates() bool {
+	return l.Lat != 0 || l.Lon != 0
+}
 
-		} else {
-			hour = strconv.Itoa(internalTime.Hour()) + "AM"
-		}
+// roundCoordinate rounds a latitude or longitude to config.CoordinateDecimals places, which is all of it that's shown,
+// saved or shared. At 2, the default, that's within about a kilometer.
+func roundCoordinate(degrees float64) float64 {
 
-	} else {
-		hour = strconv.Itoa(internalTime.Hour()) + ":00"
-	}
+	// under stateMu, since the background refresh's requests are logged and recorded with rounded coordinates too
+	stateMu.RLock()
+	scale := math.Pow(10, float64(config.CoordinateDecimals))
+	stateMu.RUnlock()
 
-	return fmt.Sprintf("%s, %s %d, %d", hour, codesToMonth[int(internalTime.Month())], internalTime.Day(), internalTime.Year())
+	return math.Round(degrees*scale) / scale
 }
 
-func setTime(args []string) string {
+// coarsened is l with its coordinates rounded, for anything that leaves weth
+func (l Location) coarsened() Location {
+	l.Lat = roundCoordinate(l.Lat)
+	l.Lon = roundCoordinate(l.Lon)
+	return l
+}
 
-	if len(args) == 0 {
-		internalTime = time.Now()
-		return "  set time to " + internalTime.Format(time.DateOnly) + " Hour: " + strconv.Itoa(internalTime.Hour())
-	}
+func formatCoordinates(lat float64, lon float64) string {
 
-	if args[0] == "--help" || args[0] == "-h" {
-		return usageStrings["setTime"]
+	stateMu.RLock()
+	decimals := config.CoordinateDecimals
+	stateMu.RUnlock()
+
+	return fmt.Sprintf("%.*f, %.*f", decimals, roundCoordinate(lat), decimals, roundCoordinate(lon))
+}
+
+// quiet leaves out the startup banner and the prompt, so only what commands print is printed
+var quiet bool
+
+var internalLocation Location
+var defaultLocation Location
+
+// how LOCATION and the default location were found, for explain, e.g. "looked up from your IP address"
+var locationSource, defaultLocationSource string
+
+func printTime() string {
+	return formatTime(internalTime)
+}
+
+func formatTime(t time.Time) string {
+	return fmt.Sprintf("%s, %s %d, %d", formatHour(t), monthName(t.Month()), t.Day(), t.Year())
+}
+
+func setTime(args []string) (string, error) {
+
+	args, base := anchorFlag(args)
+
+	if len(args) == 0 {
+		setInternalTime(time.Now().In(displayZone()))
+		return "  set time to " + internalTime.Format(time.DateOnly) + " Hour: " + strconv.Itoa(internalTime.Hour()), nil
 	}
 
 	const helpMessage = "\n  for detailed usage, enter: settime --help"
 
+	if args[0] == "--wizard" {
+		return timeWizard()
+	}
+
 	if strings.HasPrefix(args[0], "--military=") {
 
 		userInput := strings.TrimPrefix(args[0], "--military=")
@@ -94,24 +143,52 @@ func setTime(args []string) string {
 		desiredVal, err := strconv.ParseBool(userInput)
 
 		if err != nil {
-			return "  usage: settime --military=<BOOLEAN VALUE>" + helpMessage
+			return "", usageError{"  usage: settime --military=<BOOLEAN VALUE>" + helpMessage}
 		}
 
 		militaryTime = desiredVal
 
 		if desiredVal {
-			return "  military time enabled"
+			return "  military time enabled", nil
 		}
 
-		return "  military time disabled"
+		return "  military time disabled", nil
 
 	}
 
-	var stateValues = map[string]int{"Hour": internalTime.Hour(), "Day": internalTime.Day(), "Month": int(internalTime.Month()), "Year": internalTime.Year()}
+	if args[0] == "--iso" || strings.HasPrefix(args[0], "--iso=") {
+
+		userInput := strings.TrimPrefix(args[0], "--iso=")
+		if args[0] == "--iso" {
+			if len(args) < 2 {
+				return "", usageError{"  usage: settime --iso=<TIMESTAMP>" + helpMessage}
+			}
+			userInput = args[1]
+		}
+
+		parsed, err := parseISOTime(userInput)
+		if err != nil {
+			return "", errors.New("Expected a timestamp like 2024-06-03T14:30:00 or 2024-06-03T14:30:00-07:00, got " + userInput + helpMessage)
+		}
+
+		setInternalTime(parsed)
+		return "  set time to: " + printTime(), nil
+	}
+
+	args = dateWords(args)
+
+	// with --from-now, values left as * are the real current time's as well as relative ones counting from it
+	var stateValues = map[string]int{"Hour": base.Hour(), "Day": base.Day(), "Month": int(base.Month()), "Year": base.Year()}
 	var stateNames = [...]string{"Hour", "Day", "Month", "Year"}
 
 	var bound = min(len(stateNames), len(args))
 
+	// relative values are allowed to roll over into the next day or month, but absolute ones have to make sense
+	absoluteDay := false
+
+	// a two-digit year is confirmed with the year it was taken to mean
+	expandedYear := ""
+
 	for i := 0; i < bound; i++ {
 
 		if args[i] == "*" {
@@ -125,177 +202,703 @@ func setTime(args []string) string {
 
 			relNum, error := strconv.Atoi(args[i][width:])
 			if error != nil {
-				return "  Error: Expected a number for " + stateNames[i] + ", got " + args[i][width:] + helpMessage
+				return "", errors.New("Expected a number for " + stateNames[i] + ", got " + args[i][width:] + helpMessage)
 			}
 			stateValues[stateNames[i]] += relNum
 			continue
 		}
 
-		if stateNames[i] != "Month" {
-			absNum, error := strconv.Atoi(args[i])
+		if stateNames[i] == "Hour" {
+			hour, error := parseHour(args[i])
 			if error != nil {
-				return "  Error: Expected a number for " + stateNames[i] + ", got " + args[i] + helpMessage
+				return "", errors.New(error.Error() + helpMessage)
 			}
-			stateValues[stateNames[i]] = absNum
+			if hour < 0 || hour > 23 {
+				return "", errors.New("Expected Hour in range 0-23, got " + args[i] + helpMessage)
+			}
+			stateValues[stateNames[i]] = hour
 
-		} else {
+		} else if stateNames[i] == "Year" {
+			year, error := parseYear(args[i])
+			if error != nil {
+				return "", errors.New(error.Error() + helpMessage)
+			}
+			stateValues[stateNames[i]] = year
+			if len(args[i]) == 2 {
+				expandedYear = fmt.Sprintf(" (%s read as %d)", args[i], year)
+			}
 
-			monthNum, error := strconv.Atoi(args[i])
-			if error == nil {
-				if monthNum < 1 || monthNum > 12 {
-					return "  Error: Expected Month number in range 1-12, got " + strconv.Itoa(monthNum) + helpMessage
-				}
-				stateValues[stateNames[i]] = monthNum
-				continue
+		} else if stateNames[i] == "Day" {
+			day, error := parseDay(args[i])
+			if error != nil {
+				return "", errors.New(error.Error() + helpMessage)
 			}
+			stateValues[stateNames[i]] = day
+			absoluteDay = true
 
-			copy := strings.ToLower(args[i])
+		} else {
 
-			if validMonthCodes[copy] != 0 {
-				stateValues[stateNames[i]] = validMonthCodes[copy]
-				continue
+			monthNum, error := parseMonth(args[i])
+			if error != nil {
+				return "", errors.New(error.Error() + helpMessage)
 			}
-
-			return "  Error: Expected a valid month code. Got " + args[i] + helpMessage
+			stateValues[stateNames[i]] = monthNum
 		}
 
 	}
 
-	// TODO: when we add support for locations, we need this last parameter to be the timezone associated with the
-	// current standing location.
+	if absoluteDay {
+		daysInMonth := daysIn(time.Month(stateValues["Month"]), stateValues["Year"])
+		if stateValues["Day"] < 1 || stateValues["Day"] > daysInMonth {
+			return "", errors.New("Expected Day in range 1-" + strconv.Itoa(daysInMonth) + ", got " + strconv.Itoa(stateValues["Day"]) + helpMessage)
+		}
+	}
 
-	internalTime = time.Date(stateValues["Year"], time.Month(stateValues["Month"]), stateValues["Day"], stateValues["Hour"], 0, 0, 0, time.Local)
-	return "  set time to: " + printTime()
+	// the hour is on LOCATION's clock, with the offset in effect on that date
+	resolved := time.Date(stateValues["Year"], time.Month(stateValues["Month"]), stateValues["Day"], stateValues["Hour"], 0, 0, 0, displayZone())
+
+	if resolved.Year() < minYear || resolved.Year() > maxYear {
+		return "", errors.New("Expected a Year from " + strconv.Itoa(minYear) + " to " + strconv.Itoa(maxYear) + ", got " + strconv.Itoa(resolved.Year()) + helpMessage)
+	}
+
+	setInternalTime(resolved)
+	return "  set time to: " + printTime() + expandedYear, nil
 }
 
-func getTime([]string) string {
-	return printTime()
+// two-digit years before twoDigitYearPivot are this century's, and the rest are last century's
+const twoDigitYearPivot = 69
+
+// parseYear reads a year, expanding two digits so that 24 is 2024 and 69 is 1969.
+func parseYear(value string) (int, error) {
+
+	year, err := strconv.Atoi(value)
+	if err != nil || year < 0 {
+		return 0, errors.New("Expected a number for Year, got " + value)
+	}
+
+	if len(value) == 2 {
+		if year < twoDigitYearPivot {
+			return 2000 + year, nil
+		}
+		return 1900 + year, nil
+	}
+
+	return year, nil
 }
 
-func getLocation([]string) string {
-	return fmt.Sprintf("Location: %s %s, %s", internalLocation.City, internalLocation.Region, internalLocation.Country)
+// dateWords lets dates be written the way they're said: a "the" before the day is left out, and a month name can
+// come before the day, so settime 9 june the 3rd 2024 is settime 9 3 june 2024.
+func dateWords(args []string) []string {
+
+	args = slices.DeleteFunc(slices.Clone(args), func(arg string) bool { return strings.EqualFold(arg, "the") })
+
+	if len(args) < 3 {
+		return args
+	}
+
+	if _, err := strconv.Atoi(args[1]); err == nil {
+		return args
+	}
+
+	if _, isMonth := lookupMonth(args[1]); !isMonth {
+		return args
+	}
+
+	if _, err := parseDay(args[2]); err == nil || args[2] == "*" {
+		args[1], args[2] = args[2], args[1]
+	}
+	return args
 }
 
-func setLocation(args []string) string {
+// parseDay reads a day of the month, either a number or an ordinal like "3rd" or "21st".
+func parseDay(value string) (int, error) {
 
-	if len(args) == 0 {
-		internalLocation.City = defaultLocation.City
-		internalLocation.Region = defaultLocation.Region
-		internalLocation.Country = defaultLocation.Country
-		return fmt.Sprintf("Location: %s %s, %s", internalLocation.City, internalLocation.Region, internalLocation.Country)
+	number := strings.ToLower(value)
+	suffix := ""
+
+	for _, ending := range []string{"st", "nd", "rd", "th"} {
+		if strings.HasSuffix(number, ending) {
+			suffix = ending
+			number = strings.TrimSuffix(number, ending)
+		}
 	}
 
-	var stateValues = map[string]string{"City": internalLocation.City, "Region": internalLocation.Region, "Country": internalLocation.Country}
-	var stateNames = [...]string{"City", "Region", "Country"}
+	day, err := strconv.Atoi(number)
+	if err != nil || suffix != "" && day < 0 {
+		return 0, errors.New("Expected a number for Day, like 3 or 3rd, got " + value)
+	}
 
-	bound := min(len(stateNames), len(args))
+	if suffix != "" && suffix != ordinalSuffix(day) {
+		return 0, fmt.Errorf("Expected %d%s for Day, got %s", day, ordinalSuffix(day), value)
+	}
 
-	for i := 0; i < bound; i++ {
+	return day, nil
+}
 
-		if args[i] == "*" {
-			continue
+// ordinalSuffix is what goes after n to make it an ordinal: 1st, 2nd, 3rd, 4th, but 11th, 12th and 13th
+func ordinalSuffix(n int) string {
+
+	if n%100 >= 11 && n%100 <= 13 {
+		return "th"
+	}
+
+	switch n % 10 {
+	case 1:
+		return "st"
+	case 2:
+		return "nd"
+	case 3:
+		return "rd"
+	}
+	return "th"
+}
+
+// parseMonth reads a month number from 1 to 12, or a month code like "june" or "jun".
+func parseMonth(value string) (int, error) {
+
+	monthNum, err := strconv.Atoi(value)
+	if err == nil {
+		if monthNum < 1 || monthNum > 12 {
+			return 0, errors.New("Expected Month number in range 1-12, got " + strconv.Itoa(monthNum))
 		}
+		return monthNum, nil
+	}
 
-		stateValues[stateNames[i]] = args[i]
+	if code, ok := lookupMonth(value); ok {
+		return code, nil
 	}
 
-	internalLocation.City = stateValues["City"]
-	internalLocation.Region = stateValues["Region"]
-	internalLocation.Country = stateValues["Country"]
-	return fmt.Sprintf("Location: %s %s, %s", internalLocation.City, internalLocation.Region, internalLocation.Country)
+	return 0, errors.New("Expected a valid month code. Got " + value)
+}
 
-	// TODO: make sure the location we use is a valid location. IDK how we will do that.
+// daysIn is the number of days in month. Day 0 of the following month is the last day of this one.
+func daysIn(month time.Month, year int) int {
+	return time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC).Day()
 }
 
-func requestLocation() {
+// parseHour reads a 24-hour value like "14", or a 12-hour one with an am/pm suffix like "2pm" or "12AM".
+func parseHour(value string) (int, error) {
+
+	number := strings.ToLower(value)
+	suffix := ""
+
+	if strings.HasSuffix(number, "am") || strings.HasSuffix(number, "pm") {
+		suffix = number[len(number)-2:]
+		number = number[:len(number)-2]
+	}
 
-	resp, err := http.Get("https://api64.ipify.org")
+	hour, err := strconv.Atoi(number)
 	if err != nil {
-		log.Fatal(err)
+		return 0, errors.New("Expected a number for Hour, got " + value)
 	}
-	defer resp.Body.Close()
 
-	body, err := io.ReadAll(resp.Body)
+	if suffix == "" {
+		return hour, nil
+	}
 
-	if err != nil {
-		log.Fatal(err)
+	if hour < 1 || hour > 12 {
+		return 0, errors.New("Expected an hour from 1 to 12 before am/pm, got " + value)
 	}
 
-	ipAddr := string(body)
+	// 12am is midnight and 12pm is noon
+	hour %= 12
+	if suffix == "pm" {
+		hour += 12
+	}
+
+	return hour, nil
+}
 
-	locResp, locErr := http.Get("http://ip-api.com/json/" + ipAddr)
+// parseClock reads a time of day like "17", "17:30", "5pm", or "5:30pm".
+func parseClock(value string) (hour int, minute int, err error) {
 
-	if locErr != nil {
-		log.Fatal(locErr)
+	clock := strings.ToLower(value)
+	suffix := ""
+
+	if strings.HasSuffix(clock, "am") || strings.HasSuffix(clock, "pm") {
+		suffix = clock[len(clock)-2:]
+		clock = clock[:len(clock)-2]
 	}
 
-	defer resp.Body.Close()
+	hourText, minuteText, hasMinutes := strings.Cut(clock, ":")
 
-	body, err = io.ReadAll(locResp.Body)
+	if hasMinutes {
+		minute, err = strconv.Atoi(minuteText)
+		if err != nil || minute < 0 || minute > 59 {
+			return 0, 0, errors.New("Expected minutes from 00 to 59, got " + value)
+		}
+	}
 
+	hour, err = parseHour(hourText + suffix)
 	if err != nil {
-		log.Fatal(err)
+		return 0, 0, err
+	}
+
+	if hour < 0 || hour > 23 {
+		return 0, 0, errors.New("Expected Hour in range 0-23, got " + value)
 	}
 
-	// now we need to parse this json response. How do we do that?
+	return hour, minute, nil
+}
 
-	parseErr := json.Unmarshal(body, &defaultLocation)
+// parseISOTime reads an RFC3339 timestamp. Without a zone, it's taken to be in the current location's timezone.
+func parseISOTime(value string) (time.Time, error) {
 
-	if parseErr != nil {
-		log.Fatal(parseErr)
+	if parsed, err := time.Parse(time.RFC3339, value); err == nil {
+		return parsed.In(displayZone()), nil
 	}
 
+	return time.ParseInLocation("2006-01-02T15:04:05", value, displayZone())
 }
 
-func main() {
+// resetTime is the explicit form of running settime with no arguments.
+func resetTime([]string) (string, error) {
+	setInternalTime(time.Now().In(displayZone()))
+	return "  set time to: " + printTime(), nil
+}
+
+// plural translates unit before counting it. Every unit is made plural with an s in the languages weth has so far.
+func plural(count int, unit string) string {
+	if count == 1 {
+		return "1 " + tr(unit)
+	}
+	return strconv.Itoa(count) + " " + tr(unit) + "s"
+}
+
+// relativeToNow describes how far t is from the real current time, e.g. "(in 18 hours)" or "(2 days ago)".
+func relativeToNow(t time.Time) string {
+
+	offset := time.Until(t)
+
+	if offset.Abs() < time.Minute {
+		return tr("(now)")
+	}
+
+	distance := offset.Abs()
+	var amount string
+
+	switch {
+	case distance >= 24*time.Hour:
+		amount = plural(int(distance.Round(24*time.Hour)/(24*time.Hour)), "day")
+	case distance >= time.Hour:
+		amount = plural(int(distance.Round(time.Hour)/time.Hour), "hour")
+	default:
+		amount = plural(int(distance.Round(time.Minute)/time.Minute), "minute")
+	}
+
+	if offset > 0 {
+		return fmt.Sprintf(tr("(in %s)"), amount)
+	}
+	return fmt.Sprintf(tr("(%s ago)"), amount)
+}
+
+// hoursFromNow is how many hours the hour containing t is from the one the real current time is in, like (+3h),
+// (-5h) or (now). Two days or more out it's in days and hours, like (+2d4h).
+func hoursFromNow(t time.Time) string {
+
+	hours := int(t.Truncate(time.Hour).Sub(time.Now().Truncate(time.Hour)) / time.Hour)
+
+	if hours == 0 {
+		return tr("(now)")
+	}
+
+	sign := "+"
+	if hours < 0 {
+		sign, hours = "-", -hours
+	}
+
+	if hours < 48 {
+		return fmt.Sprintf("(%s%dh)", sign, hours)
+	}
+	if hours%24 == 0 {
+		return fmt.Sprintf("(%s%dd)", sign, hours/24)
+	}
+	return fmt.Sprintf("(%s%dd%dh)", sign, hours/24, hours%24)
+}
+
+// daysFromNow is how many days date is from the real current date, like (+2d), (-1d) or (today). date is midnight UTC
+// on the day, like DailyConditions.Date.
+func daysFromNow(date time.Time) string {
 
-	requestLocation()
+	year, month, day := time.Now().In(displayZone()).Date()
+	today := time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
 
-	fmt.Println("Welcome to the weth REPL! Type 'help' to print a list of commands")
-	fmt.Printf("Using location: %s %s, %s\n", defaultLocation.City, defaultLocation.Region, defaultLocation.Country)
+	days := int(date.Sub(today).Round(24*time.Hour) / (24 * time.Hour))
 
-	reader := bufio.NewReader(os.Stdin)
+	if days == 0 {
+		return tr("(today)")
+	}
+	return fmt.Sprintf("(%+dd)", days)
+}
+
+func getTime([]string) (string, error) {
+	return printTime() + " " + relativeToNow(internalTime), nil
+}
+
+func getLocation(args []string) (string, error) {
+
+	summary := fmt.Sprintf("%s: %s %s, %s", tr("Location"), internalLocation.City, internalLocation.Region, internalLocation.Country)
+
+	if len(args) == 0 || args[0] != "--detailed" {
+		return summary, nil
+	}
+
+	coordinates := tr("not set")
+	if internalLocation.hasCoordinates() {
+		coordinates = formatCoordinates(internalLocation.Lat, internalLocation.Lon)
+	}
+
+	timezone := tr("not set")
+	if internalLocation.Timezone != "" {
+		zone, err := time.LoadLocation(internalLocation.Timezone)
+		if err != nil {
+			timezone = internalLocation.Timezone + " (unknown to this system)"
+		} else {
+			timezone = internalLocation.Timezone + " (UTC" + internalTime.In(zone).Format("-07:00") + ")"
+		}
+	}
+
+	rows := [][2]string{
+		{tr("Coordinates"), coordinates},
+		{tr("Timezone"), timezone},
+	}
+
+	if zoneOverride != nil {
+		rows = append(rows, [2]string{tr("Times shown in"), zoneOverride.String() + " (UTC" + internalTime.Format("-07:00") + "), " + tr("set with tz")})
+	}
+
+	return summary + "\n  " + alignFields(rows), nil
+}
+
+func setLocation(args []string) (string, error) {
+
+	// TIME stays the same moment, shown on the new location's clock
+	defer func() {
+		setInternalTime(internalTime.In(displayZone()))
+	}()
+
+	if len(args) == 0 {
+		return moveTo(defaultLocation, tr("the location weth started with, ")+defaultLocationSource), nil
+	}
+
+	if args[0] == "--pick" {
+		return pickLocation(args[1:])
+	}
 
-	var command2func = make(map[string]func([]string) string)
-	internalTime = time.Now()
+	if args[0] == "--zip" {
+		return setPostcode(args[1:])
+	}
 
-	internalLocation = Location{Country: defaultLocation.Country, Region: defaultLocation.Region, City: defaultLocation.City}
-	militaryTime = false
+	if args[0] == "--current" {
+		if offline {
+			return "", errors.New("can't look up the current location in offline mode")
+		}
 
-	command2func["settime"] = setTime
-	command2func["time"] = getTime
-	command2func["loc"] = getLocation
-	command2func["setloc"] = setLocation
+		if err := requestLocation(); err != nil {
+			return "", errors.New("could not look up the current location: " + err.Error())
+		}
+		saveCachedLocation()
 
-	for { // Read, Eval, Print, Loop
+		defaultLocationSource = tr("looked up from your IP address")
+		return moveTo(defaultLocation, tr("looked up from your IP address with setloc --current")), nil
+	}
 
-		fmt.Print("-> ")
+	var stateValues = map[string]string{"City": internalLocation.City, "Region": internalLocation.Region, "Country": internalLocation.Country}
+	var stateNames = [...]string{"City", "Region", "Country"}
 
-		line, err := reader.ReadString('\n')
+	if strings.Contains(args[0], "=") {
 
+		named, err := namedLocationFields(args)
 		if err != nil {
-			log.Fatal(err)
+			return "", err
 		}
 
-		line = strings.Trim(line, " \n")
+		for name, value := range named {
+			stateValues[name] = value
+		}
 
-		if utf8.RuneCountInString(line) == 0 {
-			continue
+	} else {
+
+		bound := min(len(stateNames), len(args))
+
+		for i := 0; i < bound; i++ {
+
+			if args[i] == "*" {
+				continue
+			}
+
+			stateValues[stateNames[i]] = args[i]
 		}
+	}
 
-		arguments := strings.Split(line, " ")
+	candidate := internalLocation
+	candidate.City = stateValues["City"]
+	candidate.Region = stateValues["Region"]
+	candidate.Country = stateValues["Country"]
 
-		if len(arguments) == 0 {
-			continue
+	// LOCATION only changes to a place that exists
+	resolved, err := geocode(candidate)
+	if err != nil {
+		if errors.Is(err, errNoPlaces) {
+			return "", fmt.Errorf("could not find '%s'", strings.Join(nonEmpty(candidate.City, candidate.Region, candidate.Country), ", "))
 		}
 
-		if command2func[arguments[0]] == nil {
-			fmt.Printf("  %s: command not found\n", arguments[0])
+		var ambiguous ambiguousLocationError
+		if errors.As(err, &ambiguous) {
+			pickCandidates = ambiguous.Candidates
+			return "", errors.New(err.Error() + ", or pick one with setloc --pick N")
+		}
+		return "", err
+	}
+
+	return moveTo(resolved, tr("set with setloc")), nil
+}
+
+// pickCandidates are the places the last ambiguous setloc could have meant, for setloc --pick
+var pickCandidates []Location
+
+func pickLocation(args []string) (string, error) {
+
+	if len(args) != 1 {
+		return "", errUsage("setloc")
+	}
+
+	if len(pickCandidates) == 0 {
+		return "", errors.New("there's nothing to pick from. setloc --pick is for after setloc finds more than one place")
+	}
+
+	n, err := strconv.Atoi(args[0])
+	if err != nil || n < 1 || n > len(pickCandidates) {
+		return "", fmt.Errorf("expected a number from 1 to %d, got %s", len(pickCandidates), args[0])
+	}
+
+	loc := pickCandidates[n-1]
+	pickCandidates = nil
+
+	return moveTo(loc, tr("picked with setloc --pick")), nil
+}
+
+// setPostcode moves to the place with a postal code, in the given country or else LOCATION's.
+func setPostcode(args []string) (string, error) {
+
+	if len(args) == 0 || len(args) > 2 {
+		return "", errUsage("setloc")
+	}
+
+	country := internalLocation.Country
+	if internalLocation.CountryCode != "" {
+		country = internalLocation.CountryCode
+	}
+	if len(args) == 2 {
+		country = args[1]
+	}
+
+	loc, err := geocodePostcode(args[0], country)
+	if errors.Is(err, errNoPlaces) {
+		return "", fmt.Errorf("could not find postal code %s in %s", args[0], country)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return moveTo(loc, fmt.Sprintf(tr("found from the postal code %s"), args[0])), nil
+}
+
+// moveTo makes loc LOCATION, adding it to the recent locations, and says where LOCATION is now. source is how it was
+// found, for explain. The caller keeps TIME the same moment on the new location's clock.
+func moveTo(loc Location, source string) string {
+
+	updateState(func() {
+		internalLocation = loc
+		locationSource = source
+	})
+	rememberLocation(loc)
+
+	message := fmt.Sprintf("%s: %s %s, %s", tr("Location"), internalLocation.City, internalLocation.Region, internalLocation.Country)
+
+	if units, ok := config.LocationUnits[locationKey(internalLocation)]; ok {
+		message += "\n  " + fmt.Sprintf(tr("using %s, saved for this location"), units)
+	}
+
+	return message
+}
+
+// locationFromFlag geocodes the --location flag, exiting when it can't be used since it was asked for explicitly.
+func locationFromFlag(value string) Location {
+
+	loc, err := parseLocation(value)
+	if err == nil {
+		loc, err = geocodeParsed(loc)
+	}
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "weth: --location %q: %v\n", value, err)
+		os.Exit(1)
+	}
+
+	return loc
+}
+
+// namedLocationFields reads setloc arguments like country=Canada region=Ontario. A value runs on to the next name,
+// so city=New York is one city.
+func namedLocationFields(args []string) (map[string]string, error) {
+
+	names := map[string]string{"city": "City", "region": "Region", "country": "Country"}
+	fields := map[string]string{}
+	current := ""
+
+	for _, arg := range args {
+
+		key, value, named := strings.Cut(arg, "=")
+
+		if !named {
+			fields[current] += " " + arg
 			continue
 		}
 
-		output := command2func[arguments[0]](arguments[1:])
-		fmt.Printf("  %s\n", output)
+		name, ok := names[strings.ToLower(key)]
+		if !ok {
+			return nil, errors.New("unknown field " + key + ". Use city=, region= or country=")
+		}
+
+		current = name
+		fields[current] = value
+	}
+
+	for name, value := range fields {
+		if strings.TrimSpace(value) == "" {
+			return nil, errors.New(strings.ToLower(name) + "= needs a value")
+		}
+	}
+
+	return fields, nil
+}
+
+func requestLocation() error {
+
+	loc, err := lookupIPLocation()
+	if err != nil {
+		return err
+	}
+
+	defaultLocation = loc
+	return nil
+}
+
+// lookupIPLocation finds where this machine's IP address is.
+func lookupIPLocation() (Location, error) {
+
+	body, err := getBody("https://api64.ipify.org")
+	if err != nil {
+		return Location{}, err
+	}
+
+	ipAddr := string(body)
+
+	// ip-api.com answers 200 even when it can't place the address, with the reason in message
+	var answer struct {
+		Location
+		Status  string `json:"status"`
+		Message string `json:"message"`
+	}
+
+	if err := getJSON("http://ip-api.com/json/"+ipAddr, &answer); err != nil {
+		return Location{}, err
+	}
+
+	if answer.Status == "fail" {
+		return Location{}, errors.New("ip-api.com could not locate " + ipAddr + ": " + answer.Message)
+	}
+
+	return normalizeCountry(answer.Location), nil
+}
+
+func main() {
+
+	noColor := flag.Bool("no-color", false, "disable colored output")
+	scriptPath := flag.String("script", "", "run the commands in `file`, then exit")
+	flag.BoolVar(&offline, "offline", false, "use synthetic weather data and a fixed location instead of the network")
+	verbose := flag.Bool("verbose", false, "log every outbound request to stderr")
+	locationFlag := flag.String("location", "", "start in `place`, written as \"City,Region,Country\", instead of looking it up")
+	flag.BoolVar(&jsonOutput, "json", false, "print weather as JSON, for other programs to read")
+	flag.BoolVar(&militaryTime, "military", false, "show hours on a 24-hour clock")
+	failFast := flag.Bool("fail-fast", false, "stop a script at the first command that fails")
+	flag.BoolVar(&quiet, "quiet", false, "print only what commands output, without the banner or prompt. On when stdin isn't a terminal")
+	flag.Parse()
+
+	if flag.NArg() > 0 {
+		fmt.Fprintf(os.Stderr, "unexpected argument %q\n", flag.Arg(0))
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	initColor(*noColor)
+	initDebugLog(*verbose)
+	handleInterrupts()
+	loadConfig()
+	initLanguage()
+	loadHistory()
+	initProvider()
+
+	httpClient = newLoggingDoer(newRateLimitedDoer(httpClient, config.RequestsPerMinute))
+
+	if *locationFlag != "" {
+		defaultLocation = locationFromFlag(*locationFlag)
+		defaultLocationSource = tr("given with --location")
+	} else if offline {
+		defaultLocation = offlineLocation
+		defaultLocationSource = tr("offline mode's stand-in location")
+	} else if envLocation, ok := locationFromEnv(); ok {
+		defaultLocation = envLocation
+		defaultLocationSource = tr("set by WETH_DEFAULT_LOCATION")
+	} else {
+		defaultLocationSource = tr("looked up from your IP address on an earlier run")
+		if !loadCachedLocation() {
+			if err := requestLocation(); err != nil {
+				log.Fatal(err, "\nSet WETH_DEFAULT_LOCATION=City,Region,Country to choose a location instead")
+			}
+			saveCachedLocation()
+			defaultLocationSource = tr("looked up from your IP address")
+		}
+
+		// a location from the IP address is where weth is being used, so it's the place to pick units by
+		countryDefaultUnits = countryUnits(normalizeCountry(defaultLocation).CountryCode)
+		unitsCountry = normalizeCountry(defaultLocation).Country
+	}
+
+	interactive := *scriptPath == "" && term.IsTerminal(int(os.Stdin.Fd()))
+
+	// piped in commands are usually for output that's piped on somewhere else too
+	quiet = quiet || !term.IsTerminal(int(os.Stdin.Fd()))
+
+	if !quiet {
+		if interactive {
+			fmt.Println("Welcome to the weth REPL! Type 'help' to print a list of commands")
+		}
+		fmt.Printf("Using location: %s %s, %s\n", defaultLocation.City, defaultLocation.Region, defaultLocation.Country)
+		if offline {
+			fmt.Println("Offline mode: weather data is synthetic, not a real forecast")
+		}
+	}
+
+	internalLocation = defaultLocation
+	locationSource = defaultLocationSource
+	internalTime = time.Now().In(displayZone())
+
+	r := NewREPL()
+	registerBuiltins(r)
+	r.dropShadowingAliases()
+
+	// exit statuses: 0 when every command succeeded, 1 when one failed, and 2 when weth itself or one of the
+	// commands was run wrong
+	if !interactive {
+		if status := r.RunScript(*scriptPath, *failFast); status != 0 {
+			os.Exit(status)
+		}
+		return
 	}
 
+	stopRefresh := startBackgroundRefresh()
+	r.Run()
+	stopRefresh()
 }
@@ -0,0 +1,671 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"maps"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+/*
+	Tests share weth's globals, so each one that touches them starts with useTestState, which puts them back when the
+	test ends. None of them reach the network: httpClient is a stubDoer answering from testdata, or failing the test.
+*/
+
+// useTestState saves the state commands change and restores it after the test, with the config file in a temporary
+// HOME. It starts the test in metric, in English, at offlineLocation on the fake provider.
+func useTestState(t *testing.T) {
+	t.Helper()
+
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("WETH_API_KEY", "")
+
+	savedConfig := config
+	savedConfig.Aliases = maps.Clone(config.Aliases)
+	savedConfig.LocationUnits = maps.Clone(config.LocationUnits)
+	savedClient, savedProvider := httpClient, provider
+	savedLocation, savedTime, savedZone := internalLocation, internalTime, zoneOverride
+	savedOffline, savedLanguage, savedMilitary := offline, language, militaryTime
+	savedCountryUnits, savedUnitsCountry, savedSnapshotUnits := countryDefaultUnits, unitsCountry, snapshotUnits
+	savedAsk := ask
+
+	t.Cleanup(func() {
+		config = savedConfig
+		httpClient, provider = savedClient, savedProvider
+		internalLocation, internalTime, zoneOverride = savedLocation, savedTime, savedZone
+		offline, language, militaryTime = savedOffline, savedLanguage, savedMilitary
+		countryDefaultUnits, unitsCountry, snapshotUnits = savedCountryUnits, savedUnitsCountry, savedSnapshotUnits
+		ask = savedAsk
+		clearCache()
+	})
+
+	config.Aliases = map[string]string{}
+	config.LocationUnits = map[string]string{}
+	config.Units = "metric"
+	config.FallbackProviders = nil
+	config.TemperatureDecimals = 0
+	language = "en"
+	militaryTime = false
+	zoneOverride = nil
+
+	offline = true
+	provider = withCache(newFakeProvider(""))
+	httpClient = &stubDoer{t: t}
+
+	internalLocation = offlineLocation
+	internalTime = time.Now().In(displayZone())
+
+	clearCache()
+}
+
+// stubDoer answers requests with respond, or fails the test when there isn't one. It keeps the URLs it was asked for.
+type stubDoer struct {
+	t       *testing.T
+	respond func(req *http.Request) (*http.Response, error)
+
+	mu   sync.Mutex
+	urls []string
+}
+
+func (s *stubDoer) Do(req *http.Request) (*http.Response, error) {
+
+	s.mu.Lock()
+	s.urls = append(s.urls, req.URL.String())
+	s.mu.Unlock()
+
+	if s.respond == nil {
+		s.t.Errorf("unexpected request to %s", req.URL)
+		return nil, errors.New("no network in tests")
+	}
+	return s.respond(req)
+}
+
+func (s *stubDoer) requests() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.urls...)
+}
+
+// stubResponse is a response with status and body
+func stubResponse(status int, body string) *http.Response {
+	return &http.Response{StatusCode: status, Header: http.Header{}, Body: io.NopCloser(strings.NewReader(body))}
+}
+
+// respondWith makes httpClient answer every request with status and body
+func respondWith(t *testing.T, status int, body string) *stubDoer {
+	t.Helper()
+	doer := &stubDoer{t: t, respond: func(*http.Request) (*http.Response, error) {
+		return stubResponse(status, body), nil
+	}}
+	httpClient = doer
+	return doer
+}
+
+// respondWithFixtures makes httpClient answer each request with the first file in testdata whose key is in the
+// request's URL, and fail the test for any other request
+func respondWithFixtures(t *testing.T, fixtures map[string]string) *stubDoer {
+	t.Helper()
+	doer := &stubDoer{t: t, respond: func(req *http.Request) (*http.Response, error) {
+		for key, name := range fixtures {
+			if strings.Contains(req.URL.String(), key) {
+				return stubResponse(http.StatusOK, readFixture(t, name)), nil
+			}
+		}
+		t.Errorf("no fixture for %s", req.URL)
+		return nil, errors.New("no fixture")
+	}}
+	httpClient = doer
+	return doer
+}
+
+// captureStdout is what print writes to stdout
+func captureStdout(t *testing.T, print func()) string {
+	t.Helper()
+
+	captured, err := os.CreateTemp(t.TempDir(), "stdout")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stdout := os.Stdout
+	os.Stdout = captured
+	defer func() { os.Stdout = stdout }()
+
+	print()
+
+	written, err := os.ReadFile(captured.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(written)
+}
+
+func readFixture(t *testing.T, name string) string {
+	t.Helper()
+	body, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(body)
+}
+
+// float is a pointer to value, for the optional fields of Conditions
+func float(value float64) *float64 {
+	return &value
+}
+
+// approximately compares floats that went through a conversion
+func approximately(a float64, b float64) bool {
+	return a-b < 1e-9 && b-a < 1e-9
+}
+
+// stubProvider is the offline provider with its forecast replaced by hourly, for tests that need particular hours
+type stubProvider struct {
+	fakeProvider
+	hourly func(start time.Time, count int) ([]Conditions, error)
+}
+
+func (p stubProvider) Hourly(_ float64, _ float64, start time.Time, count int) ([]Conditions, error) {
+	return p.hourly(start, count)
+}
+
+// hoursOf is an hour from start for each of temperatures
+func hoursOf(start time.Time, temperatures ...float64) []Conditions {
+	var hours []Conditions
+	for i, temperature := range temperatures {
+		hours = append(hours, Conditions{Time: start.Truncate(time.Hour).Add(time.Duration(i) * time.Hour), Temperature: temperature})
+	}
+	return hours
+}
+
+func TestParseHour(t *testing.T) {
+
+	tests := []struct {
+		value string
+		want  int
+	}{
+		{"0", 0},
+		{"14", 14},
+		{"23", 23},
+		{"1am", 1},
+		{"2pm", 14},
+		{"11PM", 23},
+		{"9Am", 9},
+
+		// 12am is midnight and 12pm is noon
+		{"12am", 0},
+		{"12pm", 12},
+	}
+
+	for _, test := range tests {
+		if hour, err := parseHour(test.value); err != nil || hour != test.want {
+			t.Errorf("parseHour(%q) = %d, %v, want %d", test.value, hour, err, test.want)
+		}
+	}
+
+	for _, value := range []string{"", "noon", "pm", "0am", "13pm", "-1pm", "2 pm", "2.5"} {
+		if hour, err := parseHour(value); err == nil {
+			t.Errorf("parseHour(%q) = %d, want an error", value, hour)
+		}
+	}
+}
+
+func TestParseClock(t *testing.T) {
+
+	tests := []struct {
+		value        string
+		hour, minute int
+	}{
+		{"17", 17, 0},
+		{"17:30", 17, 30},
+		{"5pm", 17, 0},
+		{"5:30pm", 17, 30},
+		{"12:05am", 0, 5},
+		{"0:00", 0, 0},
+	}
+
+	for _, test := range tests {
+		hour, minute, err := parseClock(test.value)
+		if err != nil || hour != test.hour || minute != test.minute {
+			t.Errorf("parseClock(%q) = %d, %d, %v, want %d, %d", test.value, hour, minute, err, test.hour, test.minute)
+		}
+	}
+
+	for _, value := range []string{"24", "17:60", "17:-1", "5:30xm", ":30", "13:00pm"} {
+		if hour, minute, err := parseClock(value); err == nil {
+			t.Errorf("parseClock(%q) = %d, %d, want an error", value, hour, minute)
+		}
+	}
+}
+
+func TestSetTime(t *testing.T) {
+	useTestState(t)
+
+	tests := []struct {
+		args []string
+		want time.Time
+	}{
+		{[]string{"9", "3", "6", "2024"}, time.Date(2024, time.June, 3, 9, 0, 0, 0, displayZone())},
+		{[]string{"2pm", "29", "2", "2024"}, time.Date(2024, time.February, 29, 14, 0, 0, 0, displayZone())},
+		{[]string{"0", "31", "12", "2200"}, time.Date(2200, time.December, 31, 0, 0, 0, 0, displayZone())},
+		{[]string{"23", "1", "1", "1900"}, time.Date(1900, time.January, 1, 23, 0, 0, 0, displayZone())},
+		{[]string{"9", "june", "the", "3rd", "2024"}, time.Date(2024, time.June, 3, 9, 0, 0, 0, displayZone())},
+
+		// relative values roll over into the next day or month
+		{[]string{"9", "30", "6", "2024"}, time.Date(2024, time.June, 30, 9, 0, 0, 0, displayZone())},
+		{[]string{"/+20", "*", "*", "*"}, time.Date(2024, time.July, 1, 5, 0, 0, 0, displayZone())},
+	}
+
+	for _, test := range tests {
+		if _, err := setTime(test.args); err != nil {
+			t.Errorf("settime %s: %v", strings.Join(test.args, " "), err)
+			continue
+		}
+		if !internalTime.Equal(test.want) {
+			t.Errorf("settime %s set TIME to %v, want %v", strings.Join(test.args, " "), internalTime, test.want)
+		}
+	}
+}
+
+func TestSetTimeOutOfRange(t *testing.T) {
+	useTestState(t)
+
+	setTime([]string{"9", "3", "6", "2024"})
+	before := internalTime
+
+	for _, args := range [][]string{
+		{"24", "3", "6", "2024"},
+		{"-1", "3", "6", "2024"},
+		{"13pm", "3", "6", "2024"},
+		{"9", "31", "6", "2024"},
+		{"9", "0", "6", "2024"},
+		{"9", "29", "2", "2023"},
+		{"9", "3", "13", "2024"},
+		{"9", "3", "0", "2024"},
+		{"9", "1", "1", "1899"},
+		{"9", "1", "1", "2201"},
+		{"9", "3", "6", "/+200"},
+		{"9", "3rd", "6", "twenty"},
+		{"/x", "3", "6", "2024"},
+	} {
+		if _, err := setTime(args); err == nil {
+			t.Errorf("settime %s succeeded, want an error", strings.Join(args, " "))
+		}
+		if !internalTime.Equal(before) {
+			t.Fatalf("settime %s moved TIME to %v though it failed", strings.Join(args, " "), internalTime)
+		}
+	}
+}
+
+func TestParseMonth(t *testing.T) {
+	useTestState(t)
+
+	tests := []struct {
+		value string
+		want  int
+	}{
+		{"1", 1},
+		{"12", 12},
+		{"june", 6},
+		{"June", 6},
+		{"JUN", 6},
+		{"sep", 9},
+		{"December", 12},
+	}
+
+	for _, test := range tests {
+		if month, err := parseMonth(test.value); err != nil || month != test.want {
+			t.Errorf("parseMonth(%q) = %d, %v, want %d", test.value, month, err, test.want)
+		}
+	}
+
+	for _, value := range []string{"0", "13", "-1", "", "juneteenth", "junio"} {
+		if month, err := parseMonth(value); err == nil {
+			t.Errorf("parseMonth(%q) = %d, want an error", value, month)
+		}
+	}
+
+	// in Spanish, the Spanish names are read as well as the English ones
+	language = "es"
+	for value, want := range map[string]int{"junio": 6, "ene": 1, "dic": 12, "june": 6} {
+		if month, err := parseMonth(value); err != nil || month != want {
+			t.Errorf("in Spanish, parseMonth(%q) = %d, %v, want %d", value, month, err, want)
+		}
+	}
+}
+
+func TestLookupIPLocation(t *testing.T) {
+	useTestState(t)
+	doer := respondWithFixtures(t, map[string]string{"ipify": "ipify.txt", "ip-api.com": "ipapi_success.json"})
+
+	loc, err := lookupIPLocation()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if loc.City != "San Francisco" || loc.Country != "United States" || loc.CountryCode != "US" || loc.Timezone != "America/Los_Angeles" {
+		t.Errorf("located at %+v", loc)
+	}
+	if loc.Lat != 37.7749 || loc.Lon != -122.4194 {
+		t.Errorf("coordinates %v, %v", loc.Lat, loc.Lon)
+	}
+
+	if requests := doer.requests(); len(requests) != 2 || requests[1] != "http://ip-api.com/json/203.0.113.7" {
+		t.Errorf("requested %q", requests)
+	}
+}
+
+func TestLookupIPLocationFailure(t *testing.T) {
+	useTestState(t)
+
+	// ip-api.com answers 200 with a status of fail when it can't place the address
+	respondWithFixtures(t, map[string]string{"ipify": "ipify.txt", "ip-api.com": "ipapi_fail.json"})
+
+	_, err := lookupIPLocation()
+	if err == nil || err.Error() != "ip-api.com could not locate 203.0.113.7: private range" {
+		t.Errorf("err = %v, want ip-api.com's message", err)
+	}
+}
+
+func TestLookupIPLocationRefused(t *testing.T) {
+	useTestState(t)
+	fakeRetrySleep(t)
+	respondWith(t, http.StatusServiceUnavailable, "")
+
+	if _, err := lookupIPLocation(); err == nil || !strings.Contains(err.Error(), "api64.ipify.org had a problem answering") {
+		t.Errorf("err = %v, want ipify's refusal", err)
+	}
+}
+
+func TestParseYear(t *testing.T) {
+
+	tests := []struct {
+		value string
+		want  int
+	}{
+		{"2024", 2024},
+		{"1969", 1969},
+		{"24", 2024},
+		{"00", 2000},
+		{"07", 2007},
+
+		// two digits below the pivot are this century's, and the rest last century's
+		{"68", 2068},
+		{"69", 1969},
+		{"99", 1999},
+
+		// only two digits are expanded
+		{"7", 7},
+		{"007", 7},
+		{"124", 124},
+	}
+
+	for _, test := range tests {
+		if year, err := parseYear(test.value); err != nil || year != test.want {
+			t.Errorf("parseYear(%q) = %d, %v, want %d", test.value, year, err, test.want)
+		}
+	}
+
+	for _, value := range []string{"", "-24", "twenty", "24AD", "2024.5"} {
+		if year, err := parseYear(value); err == nil {
+			t.Errorf("parseYear(%q) = %d, want an error", value, year)
+		}
+	}
+}
+
+func TestSetTimeTwoDigitYear(t *testing.T) {
+	useTestState(t)
+
+	output, err := setTime([]string{"9", "3", "6", "68"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if internalTime.Year() != 2068 || !strings.Contains(output, "(68 read as 2068)") {
+		t.Errorf("settime with year 68 set %d and said %q", internalTime.Year(), output)
+	}
+
+	if output, _ := setTime([]string{"9", "3", "6", "2024"}); strings.Contains(output, "read as") {
+		t.Errorf("a four digit year was confirmed: %q", output)
+	}
+}
+
+func TestHoursFromNow(t *testing.T) {
+	useTestState(t)
+
+	// halfway into an hour; any minute of it counts the same
+	hour := time.Now().Truncate(time.Hour).Add(30 * time.Minute)
+
+	tests := []struct {
+		hours int
+		want  string
+	}{
+		{0, "(now)"},
+		{3, "(+3h)"},
+		{-5, "(-5h)"},
+		{47, "(+47h)"},
+		{48, "(+2d)"},
+		{52, "(+2d4h)"},
+		{-50, "(-2d2h)"},
+		{-72, "(-3d)"},
+	}
+
+	for _, test := range tests {
+		if got := hoursFromNow(hour.Add(time.Duration(test.hours) * time.Hour)); got != test.want {
+			t.Errorf("hoursFromNow(now %+dh) = %s, want %s", test.hours, got, test.want)
+		}
+	}
+}
+
+func TestDaysFromNow(t *testing.T) {
+	useTestState(t)
+
+	year, month, day := time.Now().In(displayZone()).Date()
+	today := time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		days int
+		want string
+	}{
+		{0, "(today)"},
+		{1, "(+1d)"},
+		{6, "(+6d)"},
+		{-1, "(-1d)"},
+		{-30, "(-30d)"},
+	}
+
+	for _, test := range tests {
+		if got := daysFromNow(today.AddDate(0, 0, test.days)); got != test.want {
+			t.Errorf("daysFromNow(today %+dd) = %s, want %s", test.days, got, test.want)
+		}
+	}
+}
+
+func TestRoundCoordinate(t *testing.T) {
+	useTestState(t)
+
+	tests := []struct {
+		decimals int
+		degrees  float64
+		want     float64
+	}{
+		{2, 48.85341, 48.85},
+		{2, -95.55551, -95.56},
+		{2, 2.3488, 2.35},
+		{0, 48.85341, 49},
+		{0, -0.4, 0},
+		{4, 36.302049, 36.302},
+		{6, 40.7142712, 40.714271},
+	}
+
+	for _, test := range tests {
+		config.CoordinateDecimals = test.decimals
+		if got := roundCoordinate(test.degrees); !approximately(got, test.want) {
+			t.Errorf("roundCoordinate(%v) to %d places = %v, want %v", test.degrees, test.decimals, got, test.want)
+		}
+	}
+
+	config.CoordinateDecimals = 3
+	if got := formatCoordinates(48.85341, -95.55551); got != "48.853, -95.556" {
+		t.Errorf("formatCoordinates to 3 places = %s", got)
+	}
+}
+
+func TestCoordinatesCoarsenedOnOutput(t *testing.T) {
+	useTestState(t)
+	config.CoordinateDecimals = 2
+
+	savedDefault := defaultLocation
+	t.Cleanup(func() { defaultLocation = savedDefault })
+
+	exact := Location{City: "Paris", Country: "France", Lat: 48.85341, Lon: 2.3488, Timezone: "Europe/Paris"}
+	internalLocation, defaultLocation = exact, exact
+
+	// weth itself keeps every digit
+	if coarse := exact.coarsened(); coarse.Lat != 48.85 || coarse.Lon != 2.35 || coarse.City != "Paris" || exact.Lat != 48.85341 {
+		t.Errorf("coarsened %+v to %+v", exact, coarse)
+	}
+
+	output, err := getLocation([]string{"--detailed"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(output, "48.85, 2.35") || strings.Contains(output, "48.853") {
+		t.Errorf("loc --detailed shows\n%s\nwant the coordinates to 2 places", output)
+	}
+
+	path := filepath.Join(t.TempDir(), "snapshot.json")
+	if _, err := snapshot([]string{path}); err != nil {
+		t.Fatal(err)
+	}
+	saveCachedLocation()
+	cachePath, _ := locationCachePath()
+
+	for _, file := range []string{path, cachePath} {
+		body, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var saved struct{ Location Location }
+		if err := json.Unmarshal(body, &saved); err != nil {
+			t.Fatal(err)
+		}
+		if saved.Location.Lat != 48.85 || saved.Location.Lon != 2.35 {
+			t.Errorf("%s saved the location as %+v", filepath.Base(file), saved.Location)
+		}
+	}
+
+	if internalLocation != exact {
+		t.Errorf("saving moved LOCATION to %+v", internalLocation)
+	}
+}
+
+func TestOrdinalSuffix(t *testing.T) {
+
+	tests := map[int]string{
+		1: "st", 2: "nd", 3: "rd", 4: "th", 10: "th",
+		11: "th", 12: "th", 13: "th", 14: "th",
+		21: "st", 22: "nd", 23: "rd", 30: "th", 31: "st",
+		101: "st", 111: "th", 112: "th", 113: "th", 123: "rd",
+	}
+
+	for n, want := range tests {
+		if got := ordinalSuffix(n); got != want {
+			t.Errorf("ordinalSuffix(%d) = %s, want %s", n, got, want)
+		}
+	}
+}
+
+func TestParseDay(t *testing.T) {
+
+	tests := []struct {
+		value string
+		want  int
+	}{
+		{"7", 7},
+		{"1st", 1},
+		{"2nd", 2},
+		{"3RD", 3},
+		{"4th", 4},
+		{"11th", 11},
+		{"12th", 12},
+		{"13th", 13},
+		{"21st", 21},
+		{"22nd", 22},
+		{"23rd", 23},
+		{"31st", 31},
+	}
+
+	for _, test := range tests {
+		if got, err := parseDay(test.value); err != nil || got != test.want {
+			t.Errorf("parseDay(%q) = %d, %v, want %d", test.value, got, err, test.want)
+		}
+	}
+
+	// the wrong ending says which one it should have been
+	for value, want := range map[string]string{
+		"11st": "Expected 11th for Day, got 11st",
+		"12nd": "Expected 12th for Day, got 12nd",
+		"13rd": "Expected 13th for Day, got 13rd",
+		"21th": "Expected 21st for Day, got 21th",
+		"2st":  "Expected 2nd for Day, got 2st",
+	} {
+		if _, err := parseDay(value); err == nil || err.Error() != want {
+			t.Errorf("parseDay(%q) = %v, want %q", value, err, want)
+		}
+	}
+
+	for _, value := range []string{"", "th", "first", "3.5", "-1st"} {
+		if _, err := parseDay(value); err == nil {
+			t.Errorf("parseDay(%q) succeeded", value)
+		}
+	}
+}
+
+func TestLocationFromEnv(t *testing.T) {
+	useTestState(t)
+	offline = false
+	respondWithFixtures(t, map[string]string{"geocoding-api": "openmeteo_geocoding.json"})
+
+	t.Setenv("WETH_DEFAULT_LOCATION", "Paris,Île-de-France,France")
+	if loc, ok := locationFromEnv(); !ok || !loc.hasCoordinates() || loc.Timezone != "Europe/Paris" {
+		t.Errorf("WETH_DEFAULT_LOCATION of Paris gave %+v, %t", loc, ok)
+	}
+
+	// a place the geocoder can't find falls back to the IP lookup, as one that can't be read does
+	respondWith(t, http.StatusOK, `{"results":[]}`)
+	written := captureStdout(t, func() {
+		for _, value := range []string{"Nowhere,,Atlantis", ",,"} {
+			t.Setenv("WETH_DEFAULT_LOCATION", value)
+			if loc, ok := locationFromEnv(); ok {
+				t.Errorf("WETH_DEFAULT_LOCATION=%q was used as %+v", value, loc)
+			}
+		}
+	})
+
+	// the warnings go to stderr, out of the way of piped output
+	if written != "" {
+		t.Errorf("warnings were written to stdout: %q", written)
+	}
+}
+
+func TestMoveToTranslated(t *testing.T) {
+	useTestState(t)
+	language = "es"
+
+	paris := Location{City: "Paris", Region: "Île-de-France", Country: "France", Lat: 48.85341, Lon: 2.3488}
+	if message := moveTo(paris, "test"); !strings.HasPrefix(message, tr("Location")+": Paris Île-de-France, France") {
+		t.Errorf("moving to Paris in Spanish said %q", message)
+	}
+
+	summary, _ := getLocation(nil)
+	if message := moveTo(paris, "test"); !strings.HasPrefix(message, summary) {
+		t.Errorf("moveTo said %q where loc says %q", message, summary)
+	}
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+/*
+	Moon phases are worked out locally from how far a date is into the lunar cycle, counting from a known new moon.
+	That's accurate to within about a day, which is plenty for a forecast.
+*/
+
+// a new moon at 18:14 UTC on January 6, 2000, and the average length of a lunar cycle
+var knownNewMoon = time.Date(2000, time.January, 6, 18, 14, 0, 0, time.UTC)
+
+const synodicMonth = time.Duration(29.530588853 * 24 * float64(time.Hour))
+
+var moonPhaseNames = [...]string{"New Moon", "Waxing Crescent", "First Quarter", "Waxing Gibbous", "Full Moon", "Waning Gibbous", "Last Quarter", "Waning Crescent"}
+var moonPhaseGlyphs = [...]string{"🌑", "🌒", "🌓", "🌔", "🌕", "🌖", "🌗", "🌘"}
+
+// moonPhase returns which of the eight phases the moon is in at t, and the fraction of its face that's lit.
+func moonPhase(t time.Time) (int, float64) {
+
+	cycle := math.Mod(float64(t.Sub(knownNewMoon))/float64(synodicMonth), 1)
+	if cycle < 0 {
+		cycle++
+	}
+
+	phase := int(math.Floor(cycle*8+0.5)) % 8
+	illumination := (1 - math.Cos(2*math.Pi*cycle)) / 2
+
+	return phase, illumination
+}
+
+// formatMoon describes the moon on a day, as of noon. The glyph only shows alongside color, since terminals
+// without color are also the ones likely to mangle it.
+func formatMoon(date time.Time) string {
+
+	noon := time.Date(date.Year(), date.Month(), date.Day(), 12, 0, 0, 0, date.Location())
+	phase, illumination := moonPhase(noon)
+
+	text := fmt.Sprintf("%s (%.0f%%)", tr(moonPhaseNames[phase]), illumination*100)
+	if colorEnabled {
+		text = moonPhaseGlyphs[phase] + " " + text
+	}
+
+	return text
+}
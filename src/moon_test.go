@@ -0,0 +1,58 @@
+package main
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestMoonPhase(t *testing.T) {
+
+	// from the US Naval Observatory's tables of the moon's phases
+	tests := []struct {
+		at           time.Time
+		phase        int
+		illumination float64
+	}{
+		{knownNewMoon, 0, 0},
+		{time.Date(2000, time.January, 21, 4, 40, 0, 0, time.UTC), 4, 1},
+		{time.Date(2024, time.June, 6, 12, 38, 0, 0, time.UTC), 0, 0},
+		{time.Date(2024, time.June, 14, 5, 18, 0, 0, time.UTC), 2, 0.5},
+		{time.Date(2024, time.June, 22, 1, 8, 0, 0, time.UTC), 4, 1},
+		{time.Date(2024, time.June, 28, 21, 53, 0, 0, time.UTC), 6, 0.5},
+
+		// before the known new moon, the cycle counts backwards from it
+		{time.Date(1999, time.December, 22, 17, 31, 0, 0, time.UTC), 4, 1},
+	}
+
+	for _, test := range tests {
+		phase, illumination := moonPhase(test.at)
+		if phase != test.phase {
+			t.Errorf("on %v the moon is %s, want %s", test.at, moonPhaseNames[phase], moonPhaseNames[test.phase])
+		}
+
+		// the average cycle drifts from the real one by up to about a day, which is a few percent of the face
+		if math.Abs(illumination-test.illumination) > 0.06 {
+			t.Errorf("on %v the moon is %.2f lit, want %.2f", test.at, illumination, test.illumination)
+		}
+	}
+}
+
+func TestFormatMoon(t *testing.T) {
+	useTestState(t)
+
+	color := colorEnabled
+	t.Cleanup(func() { colorEnabled = color })
+
+	full := time.Date(2024, time.June, 22, 0, 0, 0, 0, time.UTC)
+
+	colorEnabled = false
+	if got := formatMoon(full); got != "Full Moon (99%)" {
+		t.Errorf("formatMoon = %q, want Full Moon (99%%)", got)
+	}
+
+	colorEnabled = true
+	if got := formatMoon(full); got != "🌕 Full Moon (99%)" {
+		t.Errorf("with color, formatMoon = %q, want the glyph first", got)
+	}
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+/*
+	days --with-normals shows each day's usual high and low next to the forecast, and how much warmer or cooler than
+	usual the day is. Normals come from providers that are also NormalsProviders.
+*/
+
+// DailyNormal is the usual high and low on a date
+type DailyNormal struct {
+	Date time.Time `json:"date"`
+	High float64   `json:"high_c"`
+	Low  float64   `json:"low_c"`
+}
+
+// fetchNormals gets the usual weather for count days from start, when the provider knows it
+func fetchNormals(start time.Time, count int) ([]DailyNormal, error) {
+
+	inner := uncached(provider)
+	known, ok := inner.(NormalsProvider)
+	if !ok {
+		return nil, fmt.Errorf(tr("%s doesn't know the usual weather"), inner.Name())
+	}
+
+	day := time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, start.Location())
+	key := cacheKey(inner, "normals", internalLocation.Lat, internalLocation.Lon, day, count)
+
+	return cached(key, func() ([]DailyNormal, error) {
+		return known.Normals(internalLocation.Lat, internalLocation.Lon, start, count)
+	})
+}
+
+// normalsByDate looks normals up by the date they're for
+func normalsByDate(normals []DailyNormal) map[string]DailyNormal {
+	byDate := map[string]DailyNormal{}
+	for _, normal := range normals {
+		byDate[normal.Date.Format(time.DateOnly)] = normal
+	}
+	return byDate
+}
+
+// temperatureAnomaly is how far a day's average temperature, halfway between its high and low, is from the usual
+// one, e.g. "+3°C warmer".
+func temperatureAnomaly(day DailyConditions, normal DailyNormal) string {
+	return warmerOrCooler((normal.High+normal.Low)/2, (day.High+day.Low)/2, tr("as usual"))
+}
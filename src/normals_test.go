@@ -0,0 +1,60 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTemperatureAnomaly(t *testing.T) {
+	useTestState(t)
+
+	normal := DailyNormal{High: 20, Low: 10}
+
+	tests := []struct {
+		units     string
+		high, low float64
+		want      string
+	}{
+		{"metric", 24, 12, "+3°C warmer"},
+		{"metric", 14, 10, "-3°C cooler"},
+		{"metric", 16, 14, "as usual"},
+
+		// the averages are compared as they're shown, so 15.2 against 15 is as usual
+		{"metric", 20.4, 10, "as usual"},
+		{"imperial", 24, 12, "+5°F warmer"},
+		{"both", 24, 12, "+3°C, +5°F warmer"},
+	}
+
+	for _, test := range tests {
+		config.Units = test.units
+		day := DailyConditions{High: test.high, Low: test.low}
+		if got := temperatureAnomaly(day, normal); got != test.want {
+			t.Errorf("temperatureAnomaly(%v/%v) in %s = %q, want %q", test.high, test.low, test.units, got, test.want)
+		}
+	}
+}
+
+func TestDaysWithNormals(t *testing.T) {
+	useTestState(t)
+
+	output, err := daysForecast([]string{"3", "--with-normals"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	normals, err := fetchNormals(internalTime, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	byDate := normalsByDate(normals)
+	for _, normal := range normals {
+		if byDate[normal.Date.Format(time.DateOnly)] != normal {
+			t.Errorf("normalsByDate lost %v", normal.Date)
+		}
+	}
+
+	if !strings.Contains(output, "warmer") && !strings.Contains(output, "cooler") && !strings.Contains(output, "as usual") {
+		t.Errorf("days --with-normals shows\n%s\nwithout comparing to the usual weather", output)
+	}
+}
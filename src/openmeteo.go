@@ -0,0 +1,624 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+/*
+	Open-Meteo (https://open-meteo.com) is free and doesn't need an API key. Commercial API keys are sent to its
+	customer endpoint instead. Times are requested and returned in UTC, and converted to the location's zone when
+	they're displayed.
+*/
+
+const openMeteoForecastURL = "https://api.open-meteo.com/v1/forecast"
+const openMeteoCustomerURL = "https://customer-api.open-meteo.com/v1/forecast"
+const openMeteoArchiveURL = "https://archive-api.open-meteo.com/v1/archive"
+const openMeteoCustomerArchiveURL = "https://customer-archive-api.open-meteo.com/v1/archive"
+const openMeteoGeocodingURL = "https://geocoding-api.open-meteo.com/v1/search"
+
+const openMeteoTimeLayout = "2006-01-02T15:04"
+
+const openMeteoHourlyVariables = "temperature_2m,apparent_temperature,relative_humidity_2m,dew_point_2m,wind_speed_10m,wind_gusts_10m,wind_direction_10m,weather_code,pressure_msl,uv_index,precipitation_probability"
+
+// the archive is of measurements, so it has no UV index forecast or chance of precipitation
+const openMeteoArchiveVariables = "temperature_2m,apparent_temperature,relative_humidity_2m,dew_point_2m,wind_speed_10m,wind_gusts_10m,wind_direction_10m,weather_code,pressure_msl"
+
+type openMeteo struct {
+	apiKey string
+}
+
+func newOpenMeteo(apiKey string) WeatherProvider {
+	return &openMeteo{apiKey: apiKey}
+}
+
+// the forecast API keeps the last 92 days, and forecasts 16 ahead
+func (p *openMeteo) Window() (time.Duration, time.Duration) {
+	return 92 * 24 * time.Hour, 16 * 24 * time.Hour
+}
+
+func (p *openMeteo) HasDaily() bool {
+	return true
+}
+
+func (p *openMeteo) Name() string {
+	return "open-meteo"
+}
+
+// query starts off the parameters shared by every forecast request.
+func (p *openMeteo) query(lat float64, lon float64) url.Values {
+
+	query := url.Values{}
+	query.Set("latitude", strconv.FormatFloat(lat, 'f', -1, 64))
+	query.Set("longitude", strconv.FormatFloat(lon, 'f', -1, 64))
+
+	if p.apiKey != "" {
+		query.Set("apikey", p.apiKey)
+	}
+
+	return query
+}
+
+func (p *openMeteo) url(query url.Values) string {
+
+	endpoint := openMeteoForecastURL
+	if p.apiKey != "" {
+		endpoint = openMeteoCustomerURL
+	}
+
+	return endpoint + "?" + query.Encode()
+}
+
+func (p *openMeteo) archiveURL(query url.Values) string {
+
+	endpoint := openMeteoArchiveURL
+	if p.apiKey != "" {
+		endpoint = openMeteoCustomerArchiveURL
+	}
+
+	return endpoint + "?" + query.Encode()
+}
+
+// fetch gets address, a forecast or archive request, and decodes its response.
+func (p *openMeteo) fetch(address string) (openMeteoForecast, error) {
+
+	var forecast openMeteoForecast
+	if err := getJSON(address, &forecast); err != nil {
+		return forecast, err
+	}
+
+	if forecast.Error {
+		return forecast, errors.New(forecast.Reason)
+	}
+
+	return forecast, nil
+}
+
+type openMeteoHourly struct {
+	Time        []string   `json:"time"`
+	Temperature []*float64 `json:"temperature_2m"`
+	FeelsLike   []*float64 `json:"apparent_temperature"`
+	Humidity    []*float64 `json:"relative_humidity_2m"`
+	DewPoint    []*float64 `json:"dew_point_2m"`
+	WindSpeed   []*float64 `json:"wind_speed_10m"`
+	WindGusts   []*float64 `json:"wind_gusts_10m"`
+	WindDir     []*float64 `json:"wind_direction_10m"`
+	WeatherCode []*int     `json:"weather_code"`
+	Pressure    []*float64 `json:"pressure_msl"`
+	UVIndex     []*float64 `json:"uv_index"`
+	Chance      []*float64 `json:"precipitation_probability"`
+}
+
+type openMeteoDaily struct {
+	Time          []string   `json:"time"`
+	WeatherCode   []*int     `json:"weather_code"`
+	High          []*float64 `json:"temperature_2m_max"`
+	Low           []*float64 `json:"temperature_2m_min"`
+	Chance        []*float64 `json:"precipitation_probability_max"`
+	Precipitation []*float64 `json:"precipitation_sum"`
+	WindSpeed     []*float64 `json:"wind_speed_10m_max"`
+}
+
+type openMeteoCurrent struct {
+	Time        string   `json:"time"`
+	Temperature *float64 `json:"temperature_2m"`
+	FeelsLike   *float64 `json:"apparent_temperature"`
+	Humidity    *float64 `json:"relative_humidity_2m"`
+	DewPoint    *float64 `json:"dew_point_2m"`
+	WindSpeed   *float64 `json:"wind_speed_10m"`
+	WindGusts   *float64 `json:"wind_gusts_10m"`
+	WindDir     *float64 `json:"wind_direction_10m"`
+	WeatherCode *int     `json:"weather_code"`
+	Pressure    *float64 `json:"pressure_msl"`
+	UVIndex     *float64 `json:"uv_index"`
+	Chance      *float64 `json:"precipitation_probability"`
+}
+
+type openMeteoForecast struct {
+	Current openMeteoCurrent `json:"current"`
+	Hourly  openMeteoHourly  `json:"hourly"`
+	Daily   openMeteoDaily   `json:"daily"`
+	Error   bool             `json:"error"`
+	Reason  string           `json:"reason"`
+}
+
+// at guards against the provider returning series of different lengths.
+func at[T any](values []*T, i int) *T {
+	if i >= len(values) {
+		return nil
+	}
+	return values[i]
+}
+
+func (p *openMeteo) currentQuery(lat float64, lon float64) url.Values {
+	query := p.query(lat, lon)
+	query.Set("current", openMeteoHourlyVariables)
+	return query
+}
+
+func (p *openMeteo) hourlyQuery(lat float64, lon float64, start time.Time, count int) url.Values {
+
+	start = start.UTC().Truncate(time.Hour)
+	end := start.Add(time.Duration(count-1) * time.Hour)
+
+	query := p.query(lat, lon)
+	query.Set("hourly", openMeteoHourlyVariables)
+	query.Set("start_hour", start.Format(openMeteoTimeLayout))
+	query.Set("end_hour", end.Format(openMeteoTimeLayout))
+	return query
+}
+
+func (p *openMeteo) dailyQuery(lat float64, lon float64, start time.Time, count int) url.Values {
+
+	end := start.AddDate(0, 0, count-1)
+
+	query := p.query(lat, lon)
+	query.Set("daily", "weather_code,temperature_2m_max,temperature_2m_min,precipitation_probability_max,precipitation_sum,wind_speed_10m_max")
+	query.Set("timezone", "auto")
+	query.Set("start_date", start.Format(time.DateOnly))
+	query.Set("end_date", end.Format(time.DateOnly))
+	return query
+}
+
+// Raw is the response body of the request Current, Hourly or Daily would make, with kind "now", "hours" or "days".
+func (p *openMeteo) Raw(kind string, lat float64, lon float64, start time.Time, count int) ([]byte, error) {
+
+	var query url.Values
+
+	switch kind {
+	case "now":
+		query = p.currentQuery(lat, lon)
+	case "hours":
+		query = p.hourlyQuery(lat, lon, start, count)
+	case "days":
+		query = p.dailyQuery(lat, lon, start, count)
+	default:
+		return nil, errors.New("unknown request " + kind)
+	}
+
+	return getBody(p.url(query))
+}
+
+func (p *openMeteo) Current(lat float64, lon float64) (Conditions, error) {
+
+	forecast, err := p.fetch(p.url(p.currentQuery(lat, lon)))
+	if err != nil {
+		return Conditions{}, err
+	}
+
+	current := forecast.Current
+
+	if current.Temperature == nil {
+		return Conditions{}, errors.New("no current conditions in response")
+	}
+
+	t, err := time.Parse(openMeteoTimeLayout, current.Time)
+	if err != nil {
+		return Conditions{}, fmt.Errorf("unexpected time %q in forecast", current.Time)
+	}
+
+	c := Conditions{
+		Time:        t,
+		Temperature: *current.Temperature,
+		FeelsLike:   current.FeelsLike,
+		Humidity:    current.Humidity,
+		DewPoint:    current.DewPoint,
+		WindSpeed:   current.WindSpeed,
+
+		WindGusts:     current.WindGusts,
+		WindDirection: current.WindDir,
+		Pressure:      current.Pressure,
+		UVIndex:       current.UVIndex,
+
+		PrecipitationChance: current.Chance,
+	}
+
+	if current.WeatherCode != nil {
+		c.Code = *current.WeatherCode
+	}
+
+	return c, nil
+}
+
+func (p *openMeteo) Hourly(lat float64, lon float64, start time.Time, count int) ([]Conditions, error) {
+
+	forecast, err := p.fetch(p.url(p.hourlyQuery(lat, lon, start, count)))
+	if err != nil {
+		return nil, err
+	}
+
+	return hourlyConditions(forecast.Hourly)
+}
+
+// History has the forecast API's own past days, and goes to the archive, which starts in 1940, for anything older.
+func (p *openMeteo) History(lat float64, lon float64, start time.Time, count int) ([]Conditions, error) {
+
+	if history, _ := p.Window(); time.Since(start) < history {
+		return p.Hourly(lat, lon, start, count)
+	}
+
+	start = start.UTC().Truncate(time.Hour)
+	end := start.Add(time.Duration(count-1) * time.Hour)
+
+	// the archive only takes whole days
+	query := p.query(lat, lon)
+	query.Set("hourly", openMeteoArchiveVariables)
+	query.Set("start_date", start.Format(time.DateOnly))
+	query.Set("end_date", end.Format(time.DateOnly))
+
+	forecast, err := p.fetch(p.archiveURL(query))
+	if err != nil {
+		return nil, err
+	}
+
+	hours, err := hourlyConditions(forecast.Hourly)
+	if err != nil {
+		return nil, err
+	}
+
+	return slices.DeleteFunc(hours, func(c Conditions) bool {
+		return c.Time.Before(start) || c.Time.After(end)
+	}), nil
+}
+
+// hourlyConditions reads the hours of a forecast or archive response, skipping any without a temperature.
+func hourlyConditions(hourly openMeteoHourly) ([]Conditions, error) {
+
+	var hours []Conditions
+
+	for i, stamp := range hourly.Time {
+
+		temperature := at(hourly.Temperature, i)
+		if temperature == nil {
+			continue
+		}
+
+		t, err := time.Parse(openMeteoTimeLayout, stamp)
+		if err != nil {
+			return nil, fmt.Errorf("unexpected time %q in forecast", stamp)
+		}
+
+		c := Conditions{
+			Time:        t,
+			Temperature: *temperature,
+			FeelsLike:   at(hourly.FeelsLike, i),
+			Humidity:    at(hourly.Humidity, i),
+			DewPoint:    at(hourly.DewPoint, i),
+			WindSpeed:   at(hourly.WindSpeed, i),
+
+			WindGusts:     at(hourly.WindGusts, i),
+			WindDirection: at(hourly.WindDir, i),
+			Pressure:      at(hourly.Pressure, i),
+			UVIndex:       at(hourly.UVIndex, i),
+
+			PrecipitationChance: at(hourly.Chance, i),
+		}
+
+		if code := at(hourly.WeatherCode, i); code != nil {
+			c.Code = *code
+		}
+
+		hours = append(hours, c)
+	}
+
+	return hours, nil
+}
+
+// Daily splits days at midnight in the location's own timezone.
+func (p *openMeteo) Daily(lat float64, lon float64, start time.Time, count int) ([]DailyConditions, error) {
+
+	forecast, err := p.fetch(p.url(p.dailyQuery(lat, lon, start, count)))
+	if err != nil {
+		return nil, err
+	}
+
+	var days []DailyConditions
+
+	for i, stamp := range forecast.Daily.Time {
+
+		high, low := at(forecast.Daily.High, i), at(forecast.Daily.Low, i)
+		if high == nil || low == nil {
+			continue
+		}
+
+		date, err := time.Parse(time.DateOnly, stamp)
+		if err != nil {
+			return nil, fmt.Errorf("unexpected date %q in forecast", stamp)
+		}
+
+		day := DailyConditions{
+			Date:                date,
+			High:                *high,
+			Low:                 *low,
+			PrecipitationChance: at(forecast.Daily.Chance, i),
+			Precipitation:       at(forecast.Daily.Precipitation, i),
+			WindSpeed:           at(forecast.Daily.WindSpeed, i),
+		}
+
+		if code := at(forecast.Daily.WeatherCode, i); code != nil {
+			day.Code = *code
+		}
+
+		days = append(days, day)
+	}
+
+	return days, nil
+}
+
+// the years of the archive the usual weather for a date is averaged over
+const normalYears = 10
+
+// Normals average the archive's highs and lows on the same dates over the last normalYears years, in one request
+// covering all of them.
+func (p *openMeteo) Normals(lat float64, lon float64, start time.Time, count int) ([]DailyNormal, error) {
+
+	first := time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, time.UTC)
+
+	query := p.query(lat, lon)
+	query.Set("daily", "temperature_2m_max,temperature_2m_min")
+	query.Set("timezone", "auto")
+	query.Set("start_date", first.AddDate(-normalYears, 0, 0).Format(time.DateOnly))
+	query.Set("end_date", first.AddDate(-1, 0, count-1).Format(time.DateOnly))
+
+	archive, err := p.fetch(p.archiveURL(query))
+	if err != nil {
+		return nil, err
+	}
+
+	type totals struct {
+		high, low float64
+		years     int
+	}
+
+	// keyed by month and day, so each date's years add up together
+	byDate := map[string]*totals{}
+
+	for i, stamp := range archive.Daily.Time {
+
+		high, low := at(archive.Daily.High, i), at(archive.Daily.Low, i)
+		if high == nil || low == nil || len(stamp) != len(time.DateOnly) {
+			continue
+		}
+
+		key := stamp[5:]
+		if byDate[key] == nil {
+			byDate[key] = &totals{}
+		}
+		byDate[key].high += *high
+		byDate[key].low += *low
+		byDate[key].years++
+	}
+
+	var normals []DailyNormal
+
+	for i := 0; i < count; i++ {
+		date := first.AddDate(0, 0, i)
+		if t := byDate[date.Format("01-02")]; t != nil {
+			normals = append(normals, DailyNormal{Date: date, High: t.high / float64(t.years), Low: t.low / float64(t.years)})
+		}
+	}
+
+	if len(normals) == 0 {
+		return nil, errors.New("the archive has no temperatures for these dates")
+	}
+
+	return normals, nil
+}
+
+type openMeteoPlace struct {
+	Name        string  `json:"name"`
+	Admin1      string  `json:"admin1"`
+	Country     string  `json:"country"`
+	CountryCode string  `json:"country_code"`
+	Latitude    float64 `json:"latitude"`
+	Longitude   float64 `json:"longitude"`
+	Timezone    string  `json:"timezone"`
+
+	Postcodes []string `json:"postcodes"`
+}
+
+type openMeteoPlaces struct {
+	Results []openMeteoPlace `json:"results"`
+}
+
+// errNoPlaces is returned by geocode when nothing has loc's name in its region and country
+var errNoPlaces = errors.New("no places")
+
+// placeMatches reports whether place is in region, by its name or code, and country. Empty values match anything.
+func placeMatches(place openMeteoPlace, region, country string) bool {
+
+	if region != "" && !strings.EqualFold(place.Admin1, region) && !strings.EqualFold(place.Admin1, regionName(place.CountryCode, region)) {
+		return false
+	}
+	if country != "" && !strings.EqualFold(place.Country, country) && !strings.EqualFold(place.CountryCode, country) {
+		return false
+	}
+	return true
+}
+
+// maxCandidates is how many places an ambiguous location error offers
+const maxCandidates = 5
+
+// ambiguousLocationError is returned by geocode for a city that names places in more than one region or country.
+// Candidates are already geocoded, so one can be picked without searching again.
+type ambiguousLocationError struct {
+	City       string
+	Candidates []Location
+}
+
+func (a ambiguousLocationError) Error() string {
+
+	lines := []string{fmt.Sprintf("'%s' could be any of:", a.City)}
+	for i, loc := range a.Candidates {
+		lines = append(lines, fmt.Sprintf("  %d  %s", i+1, strings.Join(nonEmpty(loc.City, loc.Region, loc.Country), ", ")))
+	}
+	lines = append(lines, "Give its region or country to be more specific")
+
+	return strings.Join(lines, "\n    ")
+}
+
+// ambiguousLocation is the error for a city that names places in more than one region or country
+func ambiguousLocation(city string, places []openMeteoPlace) error {
+
+	var candidates []Location
+	for _, place := range places[:min(len(places), maxCandidates)] {
+		candidates = append(candidates, place.location())
+	}
+
+	return ambiguousLocationError{City: city, Candidates: candidates}
+}
+
+func (place openMeteoPlace) location() Location {
+	return Location{
+		City:     place.Name,
+		Region:   place.Admin1,
+		Country:  place.Country,
+		Timezone: place.Timezone,
+		Lat:      place.Latitude,
+		Lon:      place.Longitude,
+
+		CountryCode: place.CountryCode,
+	}
+}
+
+// geocode fills in the coordinates and timezone of loc by searching for its city. The place has to be in loc's region
+// and country when they're known, and the region can be given as a code like TX where the geocoder has Texas. When no
+// place is in a region regionCodes doesn't know, any place in the country will do. A city that names places in more
+// than one region or country is an error, rather than a guess.
+func geocode(loc Location) (Location, error) {
+
+	// US, USA and United States all name the same country
+	loc = normalizeCountry(loc)
+
+	if offline {
+		return fakeGeocode(loc), nil
+	}
+
+	query := url.Values{}
+	query.Set("name", loc.City)
+	query.Set("count", "10")
+	query.Set("format", "json")
+
+	var places openMeteoPlaces
+	if err := getJSON(openMeteoGeocodingURL+"?"+query.Encode(), &places); err != nil {
+		return loc, err
+	}
+
+	// the code is compared when it's known, since the geocoder's name for a country can differ from ours
+	country := loc.Country
+	if loc.CountryCode != "" {
+		country = loc.CountryCode
+	}
+
+	var candidates []openMeteoPlace
+	for _, place := range places.Results {
+		if placeMatches(place, loc.Region, country) {
+			candidates = append(candidates, place)
+		}
+	}
+
+	if len(candidates) == 0 && loc.Region != "" {
+		for _, place := range places.Results {
+			if placeMatches(place, "", country) {
+				candidates = append(candidates, place)
+			}
+		}
+	}
+
+	if len(candidates) == 0 {
+		return loc, fmt.Errorf("%w named %s", errNoPlaces, loc.City)
+	}
+
+	// the geocoder often lists several places in the same region, like a city and its districts
+	var distinct []openMeteoPlace
+	for _, place := range candidates {
+		if !slices.ContainsFunc(distinct, func(other openMeteoPlace) bool {
+			return other.Admin1 == place.Admin1 && other.Country == place.Country
+		}) {
+			distinct = append(distinct, place)
+		}
+	}
+
+	if len(distinct) > 1 {
+		return loc, ambiguousLocation(loc.City, distinct)
+	}
+
+	best := candidates[0]
+
+	loc.Lat = best.Latitude
+	loc.Lon = best.Longitude
+	loc.Timezone = best.Timezone
+
+	// fill in whatever wasn't given, so LOCATION prints in full
+	if loc.Region == "" {
+		loc.Region = best.Admin1
+	}
+	if loc.Country == "" {
+		loc.Country = best.Country
+	}
+	loc.CountryCode = best.CountryCode
+
+	return loc, nil
+}
+
+// geocodePostcode finds the place with a postal code in country, which can be its name or code. The geocoder
+// searches postal codes as well as names, but fuzzily, so only a place listing exactly that code counts.
+func geocodePostcode(code string, country string) (Location, error) {
+
+	c, ok := lookupCountry(country)
+	if !ok {
+		return Location{}, fmt.Errorf("unknown country %s", country)
+	}
+
+	// the same code names the same made up place every time, just as a city name does
+	if offline {
+		return fakeGeocode(Location{City: code, Country: c.Name, CountryCode: c.Code}), nil
+	}
+
+	query := url.Values{}
+	query.Set("name", code)
+	query.Set("countryCode", c.Code)
+	query.Set("count", "10")
+	query.Set("format", "json")
+
+	var places openMeteoPlaces
+	if err := getJSON(openMeteoGeocodingURL+"?"+query.Encode(), &places); err != nil {
+		return Location{}, err
+	}
+
+	for _, place := range places.Results {
+		if !strings.EqualFold(place.CountryCode, c.Code) {
+			continue
+		}
+		if slices.ContainsFunc(place.Postcodes, func(postcode string) bool { return strings.EqualFold(postcode, code) }) {
+			return place.location(), nil
+		}
+	}
+
+	return Location{}, fmt.Errorf("%w with postal code %s", errNoPlaces, code)
+}
@@ -0,0 +1,332 @@
+package main
+
+import (
+	"errors"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestOpenMeteoCurrent(t *testing.T) {
+	useTestState(t)
+	doer := respondWithFixtures(t, map[string]string{"current=": "openmeteo_current.json"})
+
+	c, err := newOpenMeteo("").Current(48.8566, 2.3522)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := time.Date(2024, time.June, 3, 14, 15, 0, 0, time.UTC)
+	if !c.Time.Equal(want) {
+		t.Errorf("time = %v, want %v", c.Time, want)
+	}
+	if c.Temperature != 21.4 || c.Code != 3 {
+		t.Errorf("temperature, code = %v, %v, want 21.4, 3", c.Temperature, c.Code)
+	}
+
+	optional := map[string]struct {
+		got  *float64
+		want float64
+	}{
+		"feels like":     {c.FeelsLike, 20.9},
+		"humidity":       {c.Humidity, 58},
+		"dew point":      {c.DewPoint, 12.8},
+		"wind speed":     {c.WindSpeed, 14.3},
+		"wind gusts":     {c.WindGusts, 31},
+		"wind direction": {c.WindDirection, 247},
+		"pressure":       {c.Pressure, 1014.6},
+		"uv index":       {c.UVIndex, 5.15},
+		"chance":         {c.PrecipitationChance, 20},
+	}
+	for name, field := range optional {
+		if field.got == nil || *field.got != field.want {
+			t.Errorf("%s = %v, want %v", name, field.got, field.want)
+		}
+	}
+
+	requested, err := url.Parse(doer.requests()[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if requested.Host != "api.open-meteo.com" || requested.Query().Get("latitude") != "48.8566" || requested.Query().Get("longitude") != "2.3522" {
+		t.Errorf("requested %s", requested)
+	}
+}
+
+func TestOpenMeteoCustomerEndpoint(t *testing.T) {
+	useTestState(t)
+	doer := respondWithFixtures(t, map[string]string{"current=": "openmeteo_current.json"})
+
+	if _, err := newOpenMeteo("secret").Current(48.8566, 2.3522); err != nil {
+		t.Fatal(err)
+	}
+
+	requested, _ := url.Parse(doer.requests()[0])
+	if requested.Host != "customer-api.open-meteo.com" || requested.Query().Get("apikey") != "secret" {
+		t.Errorf("requested %s, want the customer endpoint with the key", requested)
+	}
+}
+
+func TestOpenMeteoHourly(t *testing.T) {
+	useTestState(t)
+	doer := respondWithFixtures(t, map[string]string{"hourly=": "openmeteo_hourly.json"})
+
+	start := time.Date(2024, time.June, 3, 14, 30, 0, 0, time.UTC)
+	hours, err := newOpenMeteo("").Hourly(48.8566, 2.3522, start, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the 15:00 hour has no temperature, so it's left out
+	if len(hours) != 2 {
+		t.Fatalf("got %d hours, want 2", len(hours))
+	}
+
+	first, last := hours[0], hours[1]
+	if !first.Time.Equal(time.Date(2024, time.June, 3, 14, 0, 0, 0, time.UTC)) || first.Temperature != 21.2 || first.Code != 3 {
+		t.Errorf("first hour = %v %v %v", first.Time, first.Temperature, first.Code)
+	}
+	if !last.Time.Equal(time.Date(2024, time.June, 3, 16, 0, 0, 0, time.UTC)) || last.Temperature != 22.7 || last.Code != 80 {
+		t.Errorf("last hour = %v %v %v", last.Time, last.Temperature, last.Code)
+	}
+	if last.PrecipitationChance == nil || *last.PrecipitationChance != 70 || last.WindGusts == nil || *last.WindGusts != 35.6 {
+		t.Errorf("last hour chance, gusts = %v, %v", last.PrecipitationChance, last.WindGusts)
+	}
+
+	query := mustParseQuery(t, doer.requests()[0])
+	if query.Get("start_hour") != "2024-06-03T14:00" || query.Get("end_hour") != "2024-06-03T16:00" {
+		t.Errorf("requested hours %s to %s", query.Get("start_hour"), query.Get("end_hour"))
+	}
+}
+
+func TestOpenMeteoDaily(t *testing.T) {
+	useTestState(t)
+	doer := respondWithFixtures(t, map[string]string{"daily=": "openmeteo_daily.json"})
+
+	start := time.Date(2024, time.June, 3, 9, 0, 0, 0, time.UTC)
+	days, err := newOpenMeteo("").Daily(48.8566, 2.3522, start, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the last day has no high, so it's left out
+	if len(days) != 2 {
+		t.Fatalf("got %d days, want 2", len(days))
+	}
+
+	day := days[0]
+	if !day.Date.Equal(time.Date(2024, time.June, 3, 0, 0, 0, 0, time.UTC)) || day.High != 23.1 || day.Low != 13.4 || day.Code != 61 {
+		t.Errorf("first day = %v %v/%v %v", day.Date, day.High, day.Low, day.Code)
+	}
+	if day.PrecipitationChance == nil || *day.PrecipitationChance != 70 || day.Precipitation == nil || *day.Precipitation != 4.2 || day.WindSpeed == nil || *day.WindSpeed != 18.7 {
+		t.Errorf("first day chance, precipitation, wind = %v, %v, %v", day.PrecipitationChance, day.Precipitation, day.WindSpeed)
+	}
+
+	query := mustParseQuery(t, doer.requests()[0])
+	if query.Get("start_date") != "2024-06-03" || query.Get("end_date") != "2024-06-05" || query.Get("timezone") != "auto" {
+		t.Errorf("requested %s to %s in %s", query.Get("start_date"), query.Get("end_date"), query.Get("timezone"))
+	}
+}
+
+func TestOpenMeteoErrorResponse(t *testing.T) {
+	useTestState(t)
+	respondWithFixtures(t, map[string]string{"hourly=": "openmeteo_error.json"})
+
+	_, err := newOpenMeteo("").Hourly(48.8566, 2.3522, time.Now(), 3)
+	if err == nil || err.Error() != "Cannot initialize WeatherVariable from invalid String value tempeture_2m for key hourly" {
+		t.Errorf("err = %v, want the response's reason", err)
+	}
+}
+
+func TestGeocode(t *testing.T) {
+	useTestState(t)
+	offline = false
+	respondWithFixtures(t, map[string]string{"geocoding-api": "openmeteo_geocoding.json"})
+
+	loc, err := geocode(Location{City: "Paris", Country: "France"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loc.Lat != 48.85341 || loc.Lon != 2.3488 || loc.Timezone != "Europe/Paris" || loc.Region != "Île-de-France" || loc.CountryCode != "FR" {
+		t.Errorf("Paris, France = %+v", loc)
+	}
+
+	// a region ip-api.com gives as a code is the region it stands for
+	loc, err = geocode(Location{City: "Paris", Region: "TX", Country: "United States"})
+	if err != nil || loc.Lat != 33.66094 {
+		t.Errorf("Paris, TX, United States = %+v, %v, want the one in Texas", loc, err)
+	}
+
+	loc, err = geocode(Location{City: "Paris", Region: "Tennessee", Country: "US"})
+	if err != nil || loc.Lat != 36.302 || loc.Timezone != "America/Chicago" {
+		t.Errorf("Paris, Tennessee = %+v, %v", loc, err)
+	}
+
+	if _, err := geocode(Location{City: "Paris", Country: "Japan"}); !errors.Is(err, errNoPlaces) {
+		t.Errorf("Paris, Japan: err = %v, want errNoPlaces", err)
+	}
+}
+
+func mustParseQuery(t *testing.T, address string) url.Values {
+	t.Helper()
+	u, err := url.Parse(address)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return u.Query()
+}
+
+func TestAmbiguousLocationError(t *testing.T) {
+
+	err := ambiguousLocationError{City: "Paris", Candidates: []Location{
+		{City: "Paris", Region: "Texas", Country: "United States"},
+		{City: "Paris", Country: "France"},
+	}}
+
+	want := "'Paris' could be any of:\n      1  Paris, Texas, United States\n      2  Paris, France\n    Give its region or country to be more specific"
+	if err.Error() != want {
+		t.Errorf("error is\n%s\nwant\n%s", err, want)
+	}
+
+	// no more than maxCandidates are offered
+	places := make([]openMeteoPlace, maxCandidates+3)
+	for i := range places {
+		places[i] = openMeteoPlace{Name: "Springfield", Admin1: strconv.Itoa(i)}
+	}
+	var ambiguous ambiguousLocationError
+	if !errors.As(ambiguousLocation("Springfield", places), &ambiguous) || len(ambiguous.Candidates) != maxCandidates {
+		t.Errorf("offered %d candidates, want %d", len(ambiguous.Candidates), maxCandidates)
+	}
+}
+
+func TestSetLocationAmbiguousThenPick(t *testing.T) {
+	useTestState(t)
+	offline = false
+	respondWithFixtures(t, map[string]string{"geocoding-api": "openmeteo_geocoding.json"})
+	t.Cleanup(func() { pickCandidates = nil })
+
+	before := internalLocation
+
+	_, err := setLocation([]string{"Paris", "*", "US"})
+	var ambiguous ambiguousLocationError
+	if !errors.As(err, &ambiguous) && !strings.Contains(err.Error(), "could be any of") {
+		t.Fatalf("err = %v, want it ambiguous", err)
+	}
+	if !strings.Contains(err.Error(), "Paris, Texas, United States") || !strings.Contains(err.Error(), "Paris, Tennessee, United States") || !strings.HasSuffix(err.Error(), "or pick one with setloc --pick N") {
+		t.Errorf("err = %v, want both Parises and how to pick one", err)
+	}
+
+	// LOCATION only moves once one is picked
+	if internalLocation != before {
+		t.Errorf("moved to %+v before picking", internalLocation)
+	}
+
+	if _, err := pickLocation([]string{"3"}); err == nil {
+		t.Error("picked a third of two candidates")
+	}
+
+	if _, err := pickLocation([]string{"2"}); err != nil {
+		t.Fatal(err)
+	}
+	if internalLocation.Region != "Tennessee" || internalLocation.Timezone != "America/Chicago" {
+		t.Errorf("picked %+v, want Paris, Tennessee", internalLocation)
+	}
+
+	// the candidates are used up
+	if _, err := pickLocation([]string{"1"}); err == nil {
+		t.Error("picked again with nothing left to pick from")
+	}
+}
+
+func TestGeocodePostcode(t *testing.T) {
+	useTestState(t)
+	offline = false
+	doer := respondWithFixtures(t, map[string]string{"geocoding-api": "openmeteo_postcode.json"})
+
+	// the geocoder's fuzzy matches come first, and one in another country lists the code too
+	loc, err := geocodePostcode("10001", "USA")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loc.City != "Manhattan" || loc.Region != "New York" || loc.CountryCode != "US" || loc.Timezone != "America/New_York" {
+		t.Errorf("10001 in the US is %+v, want Manhattan", loc)
+	}
+
+	query := mustParseQuery(t, doer.urls[0])
+	if query.Get("name") != "10001" || query.Get("countryCode") != "US" {
+		t.Errorf("asked the geocoder with %v", query)
+	}
+
+	if _, err := geocodePostcode("10010", "US"); !errors.Is(err, errNoPlaces) {
+		t.Errorf("a code no place lists = %v, want errNoPlaces", err)
+	}
+
+	if _, err := geocodePostcode("10001", "Atlantis"); err == nil || err.Error() != "unknown country Atlantis" {
+		t.Errorf("an unknown country = %v", err)
+	}
+}
+
+func TestGeocodePostcodeOffline(t *testing.T) {
+	useTestState(t)
+
+	first, err := geocodePostcode("SW1A 1AA", "uk")
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, _ := geocodePostcode("SW1A 1AA", "GB")
+	if first != again || first.Country != "United Kingdom" || !first.hasCoordinates() {
+		t.Errorf("offline the same postal code was %+v then %+v", first, again)
+	}
+}
+
+func TestGeocodeRegionCode(t *testing.T) {
+	useTestState(t)
+	offline = false
+	respondWithFixtures(t, map[string]string{"geocoding-api": "openmeteo_geocoding_austin.json"})
+
+	for _, region := range []string{"TX", "tx", "Texas"} {
+		loc, err := geocode(Location{City: "Austin", Region: region, Country: "US"})
+		if err != nil {
+			t.Fatalf("Austin, %s: %v", region, err)
+		}
+		if loc.Lat != 30.26715 || loc.Region != region {
+			t.Errorf("Austin, %s is %+v, want the one in Texas", region, loc)
+		}
+	}
+
+	// a region that isn't a code of the country still falls back to the whole country, which here is ambiguous
+	var ambiguous ambiguousLocationError
+	if _, err := geocode(Location{City: "Austin", Region: "Lone Star", Country: "US"}); !errors.As(err, &ambiguous) {
+		t.Errorf("Austin in an unknown region = %v, want it ambiguous", err)
+	}
+}
+
+func TestPlaceMatches(t *testing.T) {
+
+	texas := openMeteoPlace{Name: "Austin", Admin1: "Texas", Country: "United States", CountryCode: "US"}
+	victoria := openMeteoPlace{Name: "Melbourne", Admin1: "Victoria", Country: "Australia", CountryCode: "AU"}
+
+	tests := []struct {
+		place           openMeteoPlace
+		region, country string
+		want            bool
+	}{
+		{texas, "", "", true},
+		{texas, "Texas", "US", true},
+		{texas, "TX", "United States", true},
+		{texas, "MN", "US", false},
+		{texas, "TX", "CA", false},
+		{victoria, "VIC", "AU", true},
+
+		// codes are only read as the place's own country's
+		{texas, "VIC", "", false},
+	}
+
+	for _, test := range tests {
+		if got := placeMatches(test.place, test.region, test.country); got != test.want {
+			t.Errorf("placeMatches(%s, %q, %q) = %t, want %t", test.place.Admin1, test.region, test.country, got, test.want)
+		}
+	}
+}
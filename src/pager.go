@@ -0,0 +1,77 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"sync/atomic"
+
+	"golang.org/x/term"
+)
+
+// pagerCommand is PAGER split into a program and its arguments, or less -R when PAGER isn't set
+func pagerCommand() []string {
+
+	if pager := strings.Fields(os.Getenv("PAGER")); len(pager) > 0 {
+		return pager
+	}
+
+	if runtime.GOOS == "windows" {
+		return nil
+	}
+
+	return []string{"less", "-R"}
+}
+
+// unindented starts output that's printed as it is, like a --oneline status, rather than indented under the prompt.
+// printOutput takes it off.
+const unindented = "\x00"
+
+// paging is set while the pager is open
+var paging atomic.Bool
+
+// printOutput prints a command's output, through a pager if it's too tall to fit in the terminal. Anything that goes
+// wrong with the pager falls back to printing it directly.
+func printOutput(output string) {
+
+	// commands that wrote their own output have nothing left to print
+	if output == "" {
+		return
+	}
+
+	text := "  " + colorize(output) + "\n"
+	if rest, ok := strings.CutPrefix(output, unindented); ok {
+		text = colorize(rest) + "\n"
+	}
+
+	fd := int(os.Stdout.Fd())
+	if !term.IsTerminal(fd) {
+		fmt.Print(text)
+		return
+	}
+
+	_, height, err := term.GetSize(fd)
+	pager := pagerCommand()
+
+	if err != nil || len(pager) == 0 || strings.Count(text, "\n") < height {
+		fmt.Print(text)
+		return
+	}
+
+	cmd := exec.Command(pager[0], pager[1:]...)
+	cmd.Stdin = strings.NewReader(text)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	paging.Store(true)
+	defer paging.Store(false)
+
+	if err := cmd.Run(); err != nil {
+		// a pager that never started printed nothing, so the output still needs showing
+		if cmd.ProcessState == nil {
+			fmt.Print(text)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"golang.org/x/term"
+)
+
+/*
+	The prompt is "-> ", with what's worth knowing at a glance in brackets before it, like "[offline 18°C] -> ". Each
+	of promptSegments adds a part or leaves itself out. With config prompt-weather, the temperature at TIME in
+	LOCATION is one of them, and can go in the terminal's title too.
+*/
+
+// promptSegments are the parts shown in brackets before the prompt, in order. An empty one is left out.
+var promptSegments = []func() string{offlineSegment, temperatureSegment}
+
+// how long the temperature in the prompt is shown before it's looked up again
+const promptRefreshInterval = 10 * time.Minute
+
+// promptWeather is the temperature last shown in the prompt, and the place, time and provider it's for
+var promptWeather struct {
+	key         string
+	fetched     time.Time
+	temperature float64
+	ok          bool
+}
+
+func prompt() string {
+
+	if quiet {
+		return ""
+	}
+
+	var parts []string
+	for _, segment := range promptSegments {
+		if part := segment(); part != "" {
+			parts = append(parts, part)
+		}
+	}
+
+	if config.PromptWeather == "title" || config.PromptWeather == "both" {
+		setTitle()
+	}
+
+	if len(parts) == 0 {
+		return "-> "
+	}
+	return "[" + strings.Join(parts, " ") + "] -> "
+}
+
+func offlineSegment() string {
+	if offline {
+		return "offline"
+	}
+	return ""
+}
+
+func temperatureSegment() string {
+
+	if config.PromptWeather != "prompt" && config.PromptWeather != "both" {
+		return ""
+	}
+
+	temperature, ok := promptTemperature()
+	if !ok {
+		return ""
+	}
+	return formatTemperature(temperature)
+}
+
+// setTitle puts LOCATION and its temperature in the terminal's title with an OSC escape sequence
+func setTitle() {
+
+	if !term.IsTerminal(int(os.Stdout.Fd())) {
+		return
+	}
+
+	title := "weth: " + strings.Join(nonEmpty(internalLocation.City, internalLocation.Country), ", ")
+	if temperature, ok := promptTemperature(); ok {
+		title += " " + formatTemperature(temperature)
+	}
+
+	fmt.Printf("\033]0;%s\007", title)
+}
+
+// promptTemperature is the temperature at TIME in LOCATION, looked up again when either has moved or it's older than
+// promptRefreshInterval. It usually comes from the cache, since a command just looked it up. When it can't be
+// found, ok is false, and the lookup isn't tried again until something changes so a prompt doesn't keep waiting on
+// the network.
+func promptTemperature() (temperature float64, ok bool) {
+
+	if !internalLocation.hasCoordinates() || !term.IsTerminal(int(os.Stdout.Fd())) {
+		return 0, false
+	}
+
+	key := fmt.Sprintf("%s|%.4f,%.4f|%d", provider.Name(), internalLocation.Lat, internalLocation.Lon, internalTime.Truncate(time.Hour).Unix())
+
+	if promptWeather.key != key || time.Since(promptWeather.fetched) > promptRefreshInterval {
+		c, _, err := conditionsAt(internalTime)
+		promptWeather.key, promptWeather.fetched = key, time.Now()
+		promptWeather.temperature, promptWeather.ok = c.Temperature, err == nil
+	}
+
+	return promptWeather.temperature, promptWeather.ok
+}
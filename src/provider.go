@@ -0,0 +1,152 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"slices"
+	"strings"
+	"time"
+)
+
+// WeatherProvider is a source of forecasts. Implementations return values in metric units and times in UTC.
+type WeatherProvider interface {
+	Name() string
+	Current(lat float64, lon float64) (Conditions, error)
+
+	// Hourly returns count hours of conditions, starting at the hour containing start.
+	Hourly(lat float64, lon float64, start time.Time, count int) ([]Conditions, error)
+
+	// Daily returns count days of summaries, starting with the day containing start.
+	Daily(lat float64, lon float64, start time.Time, count int) ([]DailyConditions, error)
+
+	// HasDaily reports whether Daily gives the provider's own summaries. When it doesn't, days adds its hours up.
+	HasDaily() bool
+
+	// History returns count hours of conditions that have already happened, starting at the hour containing start.
+	// A provider without past weather returns errNoHistory.
+	History(lat float64, lon float64, start time.Time, count int) ([]Conditions, error)
+
+	// Window is how far before and after the real current time the provider has weather for.
+	Window() (history time.Duration, forecast time.Duration)
+}
+
+// errNoHistory is returned by History when the provider only has forecasts
+var errNoHistory = errors.New("no past weather")
+
+// RawProvider is a provider that can show the response behind its weather, for the raw command. kind is "now",
+// "hours" or "days", for the request Current, Hourly or Daily would make.
+type RawProvider interface {
+	Raw(kind string, lat float64, lon float64, start time.Time, count int) ([]byte, error)
+}
+
+// NormalsProvider is a WeatherProvider that knows the usual weather for the time of year, for days --with-normals.
+type NormalsProvider interface {
+	// Normals returns the usual high and low for count days, starting with the day containing start.
+	Normals(lat float64, lon float64, start time.Time, count int) ([]DailyNormal, error)
+}
+
+// uncached is p without the cache in front of it.
+func uncached(p WeatherProvider) WeatherProvider {
+	if c, ok := p.(cachedProvider); ok {
+		return c.WeatherProvider
+	}
+	return p
+}
+
+var providers = map[string]func(apiKey string) WeatherProvider{
+	"open-meteo": newOpenMeteo,
+	"fake":       newFakeProvider,
+}
+
+var provider WeatherProvider
+
+// the WETH_API_KEY environment variable takes precedence over the config file
+func apiKey() string {
+	if key := os.Getenv("WETH_API_KEY"); key != "" {
+		return key
+	}
+	return config.APIKey
+}
+
+func providerNames() []string {
+	var names []string
+	for name := range providers {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+func initProvider() {
+
+	chosen := withCache(newFakeProvider(""))
+	if !offline {
+		chosen = withCache(configuredProvider(config.Provider))
+	}
+
+	updateState(func() { provider = chosen })
+}
+
+// configuredProvider is the provider called name, followed by config.FallbackProviders when there are any.
+func configuredProvider(name string) WeatherProvider {
+
+	constructor, ok := providers[name]
+	if !ok {
+		constructor = newOpenMeteo
+	}
+
+	chain := []WeatherProvider{constructor(apiKey())}
+
+	for _, fallback := range config.FallbackProviders {
+
+		constructor, ok := providers[fallback]
+		if !ok {
+			printWarning("ignoring unknown fallback provider " + fallback + ". Available: " + strings.Join(providerNames(), ", "))
+			continue
+		}
+
+		if fallback != name {
+			chain = append(chain, constructor(apiKey()))
+		}
+	}
+
+	if len(chain) == 1 {
+		return chain[0]
+	}
+	return fallbackProvider{chain}
+}
+
+func setProvider(args []string) (string, error) {
+
+	if len(args) == 0 {
+		text := "provider: " + provider.Name()
+		if f, ok := uncached(provider).(fallbackProvider); ok {
+			var fallbacks []string
+			for _, p := range f.chain[1:] {
+				fallbacks = append(fallbacks, p.Name())
+			}
+			text += "\n  falling back to: " + strings.Join(fallbacks, ", ")
+		}
+		return text + "\n  available: " + strings.Join(providerNames(), ", "), nil
+	}
+
+	if _, ok := providers[args[0]]; !ok {
+		return "", errors.New("unknown provider " + args[0] + ". Available: " + strings.Join(providerNames(), ", "))
+	}
+
+	if offline {
+		return "", errors.New("can't change providers in offline mode. Turn it off first with: offline off")
+	}
+
+	chosen := withCache(configuredProvider(args[0]))
+	updateState(func() {
+		provider = chosen
+		config.Provider = args[0]
+	})
+
+	if err := saveConfig(); err != nil {
+		return "  provider set to " + args[0] + ", but could not be saved: " + err.Error(), nil
+	}
+
+	return "  provider set to " + args[0], nil
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestConfiguredProvider(t *testing.T) {
+	useTestState(t)
+
+	if name := configuredProvider("fake").Name(); name != "fake" {
+		t.Errorf("fake is %s", name)
+	}
+
+	// an unknown name in the config file still leaves weth with a provider
+	if name := configuredProvider("no-such-provider").Name(); name != "open-meteo" {
+		t.Errorf("an unknown provider is %s, want open-meteo", name)
+	}
+
+	config.FallbackProviders = []string{"open-meteo", "fake"}
+	chain, ok := configuredProvider("open-meteo").(fallbackProvider)
+	if !ok || len(chain.chain) != 2 || chain.chain[1].Name() != "fake" {
+		t.Errorf("open-meteo with fallbacks open-meteo, fake = %#v, want open-meteo then fake", chain)
+	}
+}
+
+func TestSetProvider(t *testing.T) {
+	useTestState(t)
+	offline = false
+
+	if _, err := setProvider([]string{"fake"}); err != nil {
+		t.Fatal(err)
+	}
+	if provider.Name() != "fake" || config.Provider != "fake" {
+		t.Errorf("provider is %s and config says %s, want fake", provider.Name(), config.Provider)
+	}
+
+	if _, err := setProvider([]string{"no-such-provider"}); err == nil || provider.Name() != "fake" {
+		t.Errorf("an unknown provider: err = %v, provider %s", err, provider.Name())
+	}
+
+	offline = true
+	if _, err := setProvider([]string{"open-meteo"}); err == nil {
+		t.Error("changed providers in offline mode")
+	}
+}
+
+func TestAPIKey(t *testing.T) {
+	useTestState(t)
+
+	config.APIKey = "from-config"
+	if key := apiKey(); key != "from-config" {
+		t.Errorf("apiKey() = %s, want the config's", key)
+	}
+
+	t.Setenv("WETH_API_KEY", "from-env")
+	if key := apiKey(); key != "from-env" {
+		t.Errorf("apiKey() = %s, want WETH_API_KEY's", key)
+	}
+}
+
+// the forecast commands only go through the provider, so the fake one needs no network
+func TestForecastCommandsOnFakeProvider(t *testing.T) {
+	useTestState(t)
+
+	commands := map[string]func([]string) (string, error){"now": nowWeather, "hours": hoursForecast, "days": daysForecast}
+
+	for name, command := range commands {
+		output, err := command(nil)
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+		if !strings.Contains(output, "°C") {
+			t.Errorf("%s shows no temperatures:\n%s", name, output)
+		}
+	}
+}
+
+func TestOpenMeteoHistoryFromArchive(t *testing.T) {
+	useTestState(t)
+	doer := respondWithFixtures(t, map[string]string{"archive-api": "openmeteo_archive.json"})
+
+	start := time.Date(2020, time.January, 15, 1, 0, 0, 0, time.UTC)
+	hours, err := newOpenMeteo("").History(48.8566, 2.3522, start, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the archive answers with the whole day, which is cut down to the hours asked for
+	if len(hours) != 2 || !hours[0].Time.Equal(start) || hours[0].Temperature != 3.8 || hours[1].Temperature != 3.5 {
+		t.Fatalf("hours = %+v, want 1:00 and 2:00", hours)
+	}
+	if hours[0].UVIndex != nil || hours[0].PrecipitationChance != nil {
+		t.Error("the archive has no UV index or chance of precipitation")
+	}
+
+	query := mustParseQuery(t, doer.requests()[0])
+	if query.Get("start_date") != "2020-01-15" || query.Get("end_date") != "2020-01-15" {
+		t.Errorf("requested %s to %s", query.Get("start_date"), query.Get("end_date"))
+	}
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+/*
+	The query log is what this session asked for and what came back, for log to show. Unlike history, which is every
+	line typed and is kept between runs, it only lists the commands that look up weather or move TIME or LOCATION,
+	with the first line of what each one printed, and it's gone when weth exits.
+*/
+
+// how many entries the log keeps before dropping the oldest, so a long session doesn't grow it forever
+const maxLogEntries = 200
+
+// the commands the log records
+var loggedCommands = map[string]bool{
+	"now": true, "hours": true, "days": true, "forecast": true, "batch": true, "diff": true, "threshold": true, "alerts": true, "aqi": true,
+	"settime": true, "advance": true, "rewind": true, "resettime": true, "tz": true,
+	"setloc": true, "recent": true,
+}
+
+type logEntry struct {
+	At      time.Time
+	Command string
+	Result  string
+	Failed  bool
+}
+
+var queryLog struct {
+	entries []logEntry
+	dropped int // entries numbered from 1 for the whole session, even after the oldest are dropped
+}
+
+// logCommand adds a command that just ran to the log, when it's one the log records. Only the first line of its output
+// is kept, which for the weather commands is enough to tell them apart.
+func logCommand(arguments []string, output string, err error) {
+
+	if !loggedCommands[arguments[0]] {
+		return
+	}
+
+	entry := logEntry{At: time.Now(), Command: strings.Join(arguments, " ")}
+
+	var usage usageError
+	switch {
+	case errors.As(err, &usage):
+		entry.Result, entry.Failed = tr("wrong usage"), true
+	case err != nil:
+		entry.Result, entry.Failed = err.Error(), true
+	default:
+		first, _, _ := strings.Cut(strings.TrimPrefix(output, unindented), "\n")
+		entry.Result = strings.Join(strings.Fields(first), " ")
+	}
+
+	queryLog.entries = append(queryLog.entries, entry)
+
+	if over := len(queryLog.entries) - maxLogEntries; over > 0 {
+		queryLog.entries = queryLog.entries[over:]
+		queryLog.dropped += over
+	}
+}
+
+func (e logEntry) String() string {
+
+	result := e.Result
+	if e.Failed {
+		result = tr("failed: ") + result
+	}
+
+	return fmt.Sprintf("%s  %s → %s", e.At.Format(time.TimeOnly), e.Command, result)
+}
+
+func logLines() []string {
+	var lines []string
+	for i, entry := range queryLog.entries {
+		lines = append(lines, fmt.Sprintf("%4d  %s", queryLog.dropped+i+1, entry))
+	}
+	return lines
+}
+
+func printLog(args []string) (string, error) {
+
+	if len(args) == 0 {
+		if len(queryLog.entries) == 0 {
+			return tr("nothing has been looked up yet this session"), nil
+		}
+		return strings.Join(logLines(), "\n  "), nil
+	}
+
+	switch {
+	case args[0] == "clear" && len(args) == 1:
+		queryLog.entries = nil
+		queryLog.dropped = 0
+		return tr("log cleared"), nil
+
+	case args[0] == "save" && len(args) == 2:
+		text := strings.Join(logLines(), "\n")
+		if text != "" {
+			text += "\n"
+		}
+		if err := os.WriteFile(args[1], []byte(text), 0o644); err != nil {
+			return "", errors.New("could not write " + args[1] + ": " + err.Error())
+		}
+		return tr("log saved to ") + args[1] + ", " + plural(len(queryLog.entries), "command"), nil
+	}
+
+	return "", errUsage("log")
+}
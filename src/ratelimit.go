@@ -0,0 +1,88 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+var errRateLimited = errors.New("rate limited, try again shortly")
+
+// rateLimitedDoer throttles requests with a token bucket. The bucket holds up to a minute's worth of requests and
+// refills continuously. A request that would have to wait longer than maxWait for a token fails instead.
+type rateLimitedDoer struct {
+	inner   Doer
+	rate    float64 // tokens added per second
+	burst   float64
+	maxWait time.Duration
+
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+func newRateLimitedDoer(inner Doer, perMinute int) *rateLimitedDoer {
+	return &rateLimitedDoer{
+		inner:   inner,
+		rate:    float64(perMinute) / 60,
+		burst:   float64(perMinute),
+		maxWait: 2 * time.Second,
+		tokens:  float64(perMinute),
+		last:    time.Now(),
+	}
+}
+
+// reserve takes a token, returning how long the caller must wait before it may be used. ok is false when the wait
+// would be too long, in which case no token is taken.
+func (r *rateLimitedDoer) reserve() (wait time.Duration, ok bool) {
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	r.tokens = min(r.burst, r.tokens+now.Sub(r.last).Seconds()*r.rate)
+	r.last = now
+
+	if r.tokens >= 1 {
+		r.tokens--
+		return 0, true
+	}
+
+	wait = time.Duration((1 - r.tokens) / r.rate * float64(time.Second))
+	if wait > r.maxWait {
+		return wait, false
+	}
+
+	// the token is spoken for, even though it hasn't refilled yet
+	r.tokens--
+	return wait, true
+}
+
+func (r *rateLimitedDoer) Do(req *http.Request) (*http.Response, error) {
+
+	if r.rate <= 0 {
+		return r.inner.Do(req)
+	}
+
+	wait, ok := r.reserve()
+	if !ok {
+		return nil, errRateLimited
+	}
+
+	select {
+	case <-req.Context().Done():
+		r.release()
+		return nil, req.Context().Err()
+	case <-time.After(wait):
+	}
+
+	return r.inner.Do(req)
+}
+
+// release gives back a token reserved for a request that was cancelled while it waited
+func (r *rateLimitedDoer) release() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.tokens = min(r.burst, r.tokens+1)
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func limitedRequest(t *testing.T, ctx context.Context) *http.Request {
+	t.Helper()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "https://api.open-meteo.com/v1/forecast", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return req
+}
+
+func TestRateLimitBurst(t *testing.T) {
+	inner := &stubDoer{t: t, respond: func(*http.Request) (*http.Response, error) {
+		return stubResponse(http.StatusOK, ""), nil
+	}}
+
+	// 3 a minute is one every 20 seconds, far longer than maxWait, so the fourth is turned away
+	limited := newRateLimitedDoer(inner, 3)
+
+	for i := 0; i < 3; i++ {
+		if _, err := limited.Do(limitedRequest(t, context.Background())); err != nil {
+			t.Fatalf("request %d: %v", i+1, err)
+		}
+	}
+
+	if _, err := limited.Do(limitedRequest(t, context.Background())); !errors.Is(err, errRateLimited) {
+		t.Errorf("fourth request: err = %v, want errRateLimited", err)
+	}
+	if len(inner.requests()) != 3 {
+		t.Errorf("%d requests got through, want 3", len(inner.requests()))
+	}
+}
+
+func TestRateLimitOff(t *testing.T) {
+	inner := &stubDoer{t: t, respond: func(*http.Request) (*http.Response, error) {
+		return stubResponse(http.StatusOK, ""), nil
+	}}
+	limited := newRateLimitedDoer(inner, 0)
+
+	for i := 0; i < 100; i++ {
+		if _, err := limited.Do(limitedRequest(t, context.Background())); err != nil {
+			t.Fatalf("request %d: %v", i+1, err)
+		}
+	}
+}
+
+func TestRateLimitWaitCancelled(t *testing.T) {
+	inner := &stubDoer{t: t}
+
+	// one a second, with the bucket already empty, so the request waits about a second for its token
+	limited := newRateLimitedDoer(inner, 60)
+	limited.tokens = 0
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := limited.Do(limitedRequest(t, ctx))
+
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("err = %v, want the request's context's", err)
+	}
+	if waited := time.Since(start); waited > 500*time.Millisecond {
+		t.Errorf("waited %v after the request was cancelled", waited)
+	}
+
+	// the cancelled request's token is given back
+	if wait, _ := limited.reserve(); wait > time.Second {
+		t.Errorf("next wait is %v, want no more than the second the first would have waited", wait)
+	}
+}
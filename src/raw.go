@@ -0,0 +1,65 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+)
+
+// rawWeather is the provider's response for now, hours or days at TIME, as it came back. It skips the cache unless
+// --cached is given, so it shows what the provider says right now. now, hours and days cache what they made of a
+// response rather than the response, so --cached only saves asking again for the same raw response.
+func rawWeather(args []string) (string, error) {
+
+	useCache := slices.Contains(args, "--cached")
+	args = slices.DeleteFunc(slices.Clone(args), func(arg string) bool { return arg == "--cached" })
+
+	if len(args) == 0 || len(args) > 2 || (args[0] != "now" && args[0] != "hours" && args[0] != "days") {
+		return "", errUsage("raw")
+	}
+
+	count := 1
+	if len(args) == 2 {
+		var err error
+		count, err = strconv.Atoi(args[1])
+		if err != nil || count < 1 {
+			return "", errors.New("Expected a positive count, got " + args[1])
+		}
+	}
+
+	if !internalLocation.hasCoordinates() {
+		return "", errors.New(tr("no coordinates known for this location. Try setting it again with setloc"))
+	}
+
+	inner := uncached(provider)
+	raw, ok := inner.(RawProvider)
+	if !ok {
+		return "", errors.New("the " + inner.Name() + " provider can't show its raw responses")
+	}
+
+	fetch := func() ([]byte, error) {
+		return raw.Raw(args[0], internalLocation.Lat, internalLocation.Lon, internalTime, count)
+	}
+
+	var body []byte
+	var err error
+	if useCache {
+		body, err = cached(cacheKey(inner, "raw "+args[0], internalLocation.Lat, internalLocation.Lon, internalTime, count), fetch)
+	} else {
+		body, err = fetch()
+	}
+	if err != nil {
+		return "", fmt.Errorf("%s%w", tr("could not fetch weather: "), err)
+	}
+
+	var pretty bytes.Buffer
+	if err := json.Indent(&pretty, body, "", "  "); err != nil {
+		return "", fmt.Errorf("the response isn't JSON: %s", body)
+	}
+
+	// unindented, so it can be piped into anything that reads JSON
+	return unindented + pretty.String(), nil
+}
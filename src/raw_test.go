@@ -0,0 +1,41 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestRawWeather(t *testing.T) {
+	useTestState(t)
+	offline = false
+	provider = withCache(newOpenMeteo(""))
+	doer := respondWith(t, http.StatusOK, readFixture(t, "openmeteo_current.json"))
+
+	output, err := rawWeather([]string{"now"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	body, ok := strings.CutPrefix(output, unindented)
+	if !ok || !json.Valid([]byte(body)) || !strings.HasPrefix(body, "{\n  ") {
+		t.Errorf("raw now = %q, want the indented JSON unindented", output)
+	}
+
+	// --cached asks again only the first time, and a plain raw always does
+	for _, args := range [][]string{{"now", "--cached"}, {"now", "--cached"}, {"now"}} {
+		if _, err := rawWeather(args); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(doer.urls) != 3 {
+		t.Errorf("raw asked %d times, want 3", len(doer.urls))
+	}
+
+	for _, args := range [][]string{nil, {"later"}, {"hours", "0"}, {"days", "1", "2"}} {
+		if _, err := rawWeather(args); err == nil {
+			t.Errorf("raw %q succeeded", args)
+		}
+	}
+}
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"io"
+	"os"
+	"slices"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+type lineReader interface {
+	ReadLine() (string, error)
+	Ask(question string) (string, error)
+}
+
+// ask reads one more line of input from wherever commands are coming from. Until the REPL or a script sets it up,
+// there's nothing to read.
+var ask = func(question string) (string, error) {
+	return "", io.EOF
+}
+
+// terminalReader gives line editing, arrow keys, and tab completion. The terminal is only put in raw mode while a
+// line is being read, so command output can still be printed normally.
+type terminalReader struct {
+	fd       int
+	terminal *term.Terminal
+}
+
+func (t *terminalReader) ReadLine() (string, error) {
+	return t.readWithPrompt(prompt())
+}
+
+// Ask reads an answer to question, for commands that need more input than their arguments.
+func (t *terminalReader) Ask(question string) (string, error) {
+	return t.readWithPrompt(question)
+}
+
+func (t *terminalReader) readWithPrompt(text string) (string, error) {
+
+	if width, height, err := term.GetSize(t.fd); err == nil {
+		t.terminal.SetSize(width, height)
+	}
+
+	t.terminal.SetPrompt(text)
+
+	state, err := term.MakeRaw(t.fd)
+	if err != nil {
+		return "", err
+	}
+	defer term.Restore(t.fd, state)
+
+	return t.terminal.ReadLine()
+}
+
+// newLineReader expects stdin to be a terminal. Piped input is run as a script instead.
+func newLineReader(r *REPL) lineReader {
+
+	fd := int(os.Stdin.Fd())
+
+	screen := struct {
+		io.Reader
+		io.Writer
+	}{os.Stdin, os.Stdout}
+
+	terminal := term.NewTerminal(screen, prompt())
+	terminal.History = recallOnly{&history}
+	terminal.AutoCompleteCallback = func(line string, pos int, key rune) (string, int, bool) {
+		return complete(r, line, pos, key)
+	}
+
+	return &terminalReader{fd: fd, terminal: terminal}
+}
+
+// candidates for the arguments of each command, beyond the command names themselves.
+func argumentCompletions(r *REPL, command string) []string {
+
+	switch command {
+	case "settime":
+		candidates := []string{"--help", "-h", "--wizard", "--military=true", "--military=false", "--iso=", "--from-now", "--from-time"}
+		for _, month := range monthNames[language] {
+			candidates = append(candidates, strings.ToLower(month))
+		}
+		return candidates
+	case "now":
+		return append([]string{"--oneline", "--field", "--relative"}, nowFieldNames()...)
+	case "raw":
+		return []string{"now", "hours", "days", "--cached"}
+	case "hours":
+		return []string{"--csv", "--day", "--relative"}
+	case "days":
+		return []string{"--csv", "--with-normals"}
+	case "loc":
+		return []string{"--detailed"}
+	case "setloc":
+		return []string{"--current", "--pick", "--zip"}
+	case "color":
+		return []string{"on", "off"}
+	case "colors":
+		return []string{"reset", "metric", "imperial"}
+	case "units":
+		return []string{"metric", "imperial", "both", "--for-location"}
+	case "provider":
+		return providerNames()
+	case "offline":
+		return []string{"on", "off"}
+	case "lang":
+		return languageNames()
+	case "advance", "rewind", "diff":
+		return []string{"--from-now", "--from-time"}
+	case "threshold":
+		return []string{"temp-below", "temp-above"}
+	case "log":
+		return []string{"clear", "save"}
+	case "tz":
+		return []string{"reset", "UTC"}
+	case "config":
+		return settingNames()
+	case "unalias":
+		var names []string
+		for name := range config.Aliases {
+			names = append(names, name)
+		}
+		return names
+	case "help":
+		var names []string
+		for _, command := range r.Commands() {
+			names = append(names, command.Name)
+		}
+		return append(names, "exit")
+	}
+
+	return nil
+}
+
+func complete(r *REPL, line string, pos int, key rune) (string, int, bool) {
+
+	if key != '\t' {
+		return "", 0, false
+	}
+
+	prefix := line[:pos]
+	wordStart := strings.LastIndex(prefix, " ") + 1
+	word := prefix[wordStart:]
+
+	var candidates []string
+	fields := strings.Fields(prefix)
+
+	if wordStart == 0 || len(fields) == 0 {
+		for _, command := range r.Commands() {
+			candidates = append(candidates, command.Name)
+		}
+		for name := range config.Aliases {
+			candidates = append(candidates, name)
+		}
+	} else {
+		candidates = argumentCompletions(r, fields[0])
+	}
+
+	var matches []string
+	for _, candidate := range candidates {
+		if strings.HasPrefix(candidate, word) {
+			matches = append(matches, candidate)
+		}
+	}
+
+	if len(matches) == 0 {
+		return "", 0, false
+	}
+
+	slices.Sort(matches)
+
+	completion := commonPrefix(matches)
+	if len(matches) == 1 && !strings.HasSuffix(completion, "=") {
+		completion += " "
+	}
+
+	newLine := prefix[:wordStart] + completion + line[pos:]
+	return newLine, wordStart + len(completion), true
+}
+
+func commonPrefix(words []string) string {
+
+	prefix := words[0]
+
+	for _, word := range words[1:] {
+		for !strings.HasPrefix(word, prefix) {
+			prefix = prefix[:len(prefix)-1]
+		}
+	}
+
+	return prefix
+}
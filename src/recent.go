@@ -0,0 +1,64 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// how many locations recent remembers
+const maxRecentLocations = 10
+
+// rememberLocation puts loc at the front of the recent locations, taking out any earlier visit to it.
+func rememberLocation(loc Location) {
+
+	// saved in the config, so kept only as precisely as it's shown
+	loc = loc.coarsened()
+	key := locationKey(loc)
+
+	recent := slices.DeleteFunc(slices.Clone(config.RecentLocations), func(other Location) bool {
+		return locationKey(other) == key
+	})
+
+	config.RecentLocations = append([]Location{loc}, recent[:min(len(recent), maxRecentLocations-1)]...)
+
+	if err := saveConfig(); err != nil {
+		printWarning("could not save recent locations: " + err.Error())
+	}
+}
+
+func recentLocations(args []string) (string, error) {
+
+	recent := config.RecentLocations
+
+	if len(args) == 0 {
+
+		if len(recent) == 0 {
+			return tr("no recent locations yet. setloc adds them"), nil
+		}
+
+		var lines []string
+		for i, loc := range recent {
+			lines = append(lines, fmt.Sprintf("%2d  %s", i+1, strings.Join(nonEmpty(loc.City, loc.Region, loc.Country), ", ")))
+		}
+		return strings.Join(lines, "\n  "), nil
+	}
+
+	n, err := strconv.Atoi(args[0])
+	if err != nil || len(args) > 1 {
+		return "", errUsage("recent")
+	}
+
+	if n < 1 || n > len(recent) {
+		return "", errors.New("no recent location " + args[0] + ". recent lists them")
+	}
+
+	// TIME stays the same moment, shown on the new location's clock
+	defer func() {
+		setInternalTime(internalTime.In(displayZone()))
+	}()
+
+	return moveTo(recent[n-1], tr("gone back to with recent")), nil
+}
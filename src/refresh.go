@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"time"
+)
+
+/*
+	With config background-refresh-minutes, the current weather at LOCATION is fetched again on that interval while
+	the REPL is open, so now answers from the cache straight away. The refresher copies LOCATION, TIME and the
+	provider while holding stateMu for reading, and lets go of it before fetching, so a command is never held up by
+	it. What it fetches is cached under the coordinates it fetched for, so moving LOCATION meanwhile can't mix the two
+	up. Its requests go through the same rate limited client as everything else.
+*/
+
+// how often the refresher checks whether a refresh is due, which is as fine as background-refresh-minutes goes
+const refreshCheckInterval = time.Minute
+
+// startBackgroundRefresh runs the refresher until stop is called or weth is interrupted. It always runs, and does
+// nothing while background-refresh-minutes is 0, so turning it on with config takes effect without a restart.
+func startBackgroundRefresh() (stop func()) {
+
+	ctx, cancel := context.WithCancel(requestContext)
+
+	go func() {
+
+		ticker := time.NewTicker(refreshCheckInterval)
+		defer ticker.Stop()
+
+		var last time.Time
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if refreshCurrent(last) {
+					last = time.Now()
+				}
+			}
+		}
+	}()
+
+	return cancel
+}
+
+// refreshCurrent fetches the current weather at LOCATION into the cache, if it's been background-refresh-minutes since
+// last. It reports whether it fetched, whether or not that worked, so a failing network is only tried once an
+// interval.
+func refreshCurrent(last time.Time) bool {
+
+	stateMu.RLock()
+	interval := time.Duration(config.BackgroundRefreshMinutes) * time.Minute
+	caching := config.CacheTTLMinutes > 0
+	when, loc, inner := internalTime, internalLocation, uncached(provider)
+	stateMu.RUnlock()
+
+	if interval == 0 || time.Since(last) < interval || !caching {
+		return false
+	}
+
+	// now only uses the current conditions for a TIME within the hour, so they're all that's worth keeping warm
+	if !loc.hasCoordinates() || time.Since(when).Abs() >= time.Hour {
+		return false
+	}
+
+	lat, lon := loc.Lat, loc.Lon
+
+	c, err := inner.Current(lat, lon)
+	if err != nil {
+		debugLog.Debug("background refresh failed", "provider", inner.Name(), "error", err)
+		return true
+	}
+
+	storeCached(cacheKey(inner, "current", lat, lon, time.Now(), 1), c)
+	return true
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+// Run with -race: the refresher fetches through the same client while commands move LOCATION and TIME, change the
+// settings its requests read, and look the weather up themselves.
+func TestRefreshWhileCommandsRun(t *testing.T) {
+	useTestState(t)
+	config.BackgroundRefreshMinutes = 1
+	config.CacheTTLMinutes = 10
+
+	offline, provider = false, withCache(newOpenMeteo(""))
+	respondWithFixtures(t, map[string]string{
+		"current=":  "openmeteo_current.json",
+		"hourly=":   "openmeteo_hourly.json",
+		"daily=":    "openmeteo_daily.json",
+		"geocoding": "openmeteo_geocoding.json",
+	})
+
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+
+	fetches := 0
+	go func() {
+		defer wg.Done()
+		for {
+
+			// a zero last is always due
+			if refreshCurrent(time.Time{}) {
+				fetches++
+			}
+
+			// checked after, so it fetches at least once however soon the commands finish
+			select {
+			case <-done:
+				return
+			default:
+			}
+		}
+	}()
+
+	commands := []struct {
+		run  func([]string) (string, error)
+		args []string
+	}{
+		{nowWeather, nil},
+		{setLocation, []string{"Paris", "*", "France"}},
+		{nowWeather, nil},
+		{advanceTime, []string{"30m"}},
+		{setConfig, []string{"cache-ttl-minutes", "5"}},
+		{setConfig, []string{"coordinate-decimals", "3"}},
+		{refresh, nil},
+		{resetTime, nil},
+	}
+
+	for i := 0; i < 20; i++ {
+		for _, command := range commands {
+			// not Fatal, which would leave the refresher running into the next test
+			if _, err := command.run(command.args); err != nil {
+				t.Error(err)
+			}
+		}
+	}
+
+	close(done)
+	wg.Wait()
+
+	if fetches == 0 {
+		t.Error("the refresher never fetched")
+	}
+}
+
+func TestRefreshOnlyWhenDue(t *testing.T) {
+	useTestState(t)
+	config.CacheTTLMinutes = 10
+
+	if refreshCurrent(time.Time{}) {
+		t.Error("refreshed with background-refresh-minutes 0")
+	}
+
+	config.BackgroundRefreshMinutes = 5
+	if refreshCurrent(time.Now().Add(-time.Minute)) {
+		t.Error("refreshed a minute after the last one, with an interval of 5")
+	}
+	if !refreshCurrent(time.Now().Add(-10 * time.Minute)) {
+		t.Error("didn't refresh 10 minutes after the last one")
+	}
+
+	// now doesn't use the current conditions for a TIME a day away, so they aren't kept warm
+	internalTime = time.Now().Add(24 * time.Hour)
+	if refreshCurrent(time.Time{}) {
+		t.Error("refreshed with TIME a day away")
+	}
+
+	internalTime = time.Now()
+	config.CacheTTLMinutes = 0
+	if refreshCurrent(time.Time{}) {
+		t.Error("refreshed with caching off")
+	}
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"strings"
+)
+
+// Command is something that can be typed into the REPL. Run gets the words after the command's name and returns
+// what to print, or an error when the command failed.
+type Command struct {
+	Name string
+
+	// Help is the one line summary help lists the command with, and Usage is the detailed text shown by
+	// help NAME and NAME --help.
+	Help  string
+	Usage string
+
+	Run func(args []string) (string, error)
+}
+
+// REPL reads command lines from a terminal or a script and runs them.
+type REPL struct {
+	commands map[string]Command
+	order    []string // help lists commands in the order they were registered
+}
+
+func NewREPL() *REPL {
+	return &REPL{commands: map[string]Command{}}
+}
+
+// Register adds a command, replacing any registered under the same name. A command without its own usage text gets
+// one made from its summary.
+func (r *REPL) Register(command Command) {
+
+	if command.Usage == "" {
+		command.Usage = "usage: " + command.Name + "\n    " + command.Help
+	}
+
+	if _, ok := r.commands[command.Name]; !ok {
+		r.order = append(r.order, command.Name)
+	}
+
+	r.commands[command.Name] = command
+}
+
+func (r *REPL) Lookup(name string) (Command, bool) {
+	command, ok := r.commands[name]
+	return command, ok
+}
+
+// Commands returns every registered command, in the order they were registered.
+func (r *REPL) Commands() []Command {
+	var commands []Command
+	for _, name := range r.order {
+		commands = append(commands, r.commands[name])
+	}
+	return commands
+}
+
+// Evaluate runs a single command line, printing its output to stdout or its error to stderr. It returns the error,
+// which is a usageError when the line wasn't a command used the right way.
+func (r *REPL) Evaluate(line string) error {
+
+	// runs of spaces or tabs don't make empty arguments, and a line of nothing but whitespace does nothing
+	arguments := strings.Fields(line)
+
+	if len(arguments) == 0 {
+		return nil
+	}
+
+	arguments, err := resolveAlias(arguments)
+	if err != nil {
+		printError(err)
+		return err
+	}
+
+	command, ok := r.commands[arguments[0]]
+	if !ok {
+		err := usageError{"  " + fmt.Sprintf(tr("%s: command not found"), arguments[0])}
+		printError(err)
+		return err
+	}
+
+	// every command answers --help the same way help <command> does
+	if len(arguments) > 1 && (arguments[1] == "--help" || arguments[1] == "-h") {
+		fmt.Printf("  %s\n", commandUsage(command))
+		return nil
+	}
+
+	output, err := command.Run(arguments[1:])
+	logCommand(arguments, output, err)
+
+	if err != nil {
+		printError(err)
+		return err
+	}
+
+	printOutput(output)
+	return nil
+}
+
+// Run reads commands from the terminal until 'exit' or end of input.
+func (r *REPL) Run() {
+
+	reader := newLineReader(r)
+	ask = reader.Ask
+
+	for { // Read, Eval, Print, Loop
+
+		line, err := reader.ReadLine()
+
+		if err == io.EOF {
+			fmt.Println()
+			saveHistory()
+			return
+		}
+
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		// all whitespace, so a \r from a Windows line ending or a stray tab doesn't end up in a command's name
+		line = strings.TrimSpace(line)
+
+		if line == "" {
+
+			// with config empty-line repeat, Enter on its own runs the last command again. Never exit, though,
+			// which is the last line of the previous session's history.
+			if config.EmptyLine != "repeat" || lastEntry() == "" || lastEntry() == "exit" {
+				continue
+			}
+
+			line = lastEntry()
+			fmt.Printf("  %s\n", line)
+		}
+
+		if refersToHistory(line) {
+			expanded, err := expandHistory(line)
+			if err != nil {
+				printError(err)
+				continue
+			}
+			line = expanded
+			fmt.Printf("  %s\n", line)
+		}
+
+		history.Add(line)
+
+		if line == "exit" {
+			saveHistory()
+			return
+		}
+
+		r.Evaluate(line)
+
+		if interrupted.Load() {
+			exitInterrupted()
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"testing"
+)
+
+func TestEvaluateBlankLines(t *testing.T) {
+	useTestState(t)
+
+	r := NewREPL()
+	r.Register(Command{Name: "boom", Run: func([]string) (string, error) {
+		t.Error("a blank line ran a command")
+		return "", nil
+	}})
+
+	for _, line := range []string{"", " ", "\t", " \t  \r"} {
+		if err := r.Evaluate(line); err != nil {
+			t.Errorf("Evaluate(%q) = %v, want it skipped", line, err)
+		}
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	useTestState(t)
+
+	var got []string
+	r := NewREPL()
+	r.Register(Command{Name: "echo", Run: func(args []string) (string, error) {
+		got = args
+		return "", nil
+	}})
+	r.Register(Command{Name: "fail", Run: func([]string) (string, error) {
+		return "", errors.New("failed")
+	}})
+	config.Aliases["say"] = "echo hello"
+
+	// runs of spaces and tabs don't make empty arguments
+	if err := r.Evaluate("  echo \t a   b "); err != nil || len(got) != 2 || got[0] != "a" || got[1] != "b" {
+		t.Errorf("echo got %q, %v", got, err)
+	}
+
+	if err := r.Evaluate("say world"); err != nil || len(got) != 2 || got[0] != "hello" || got[1] != "world" {
+		t.Errorf("through an alias, echo got %q, %v", got, err)
+	}
+
+	if err := r.Evaluate("fail"); err == nil || err.Error() != "failed" {
+		t.Errorf("fail returned %v", err)
+	}
+
+	if err := r.Evaluate("nosuch"); !errors.As(err, new(usageError)) {
+		t.Errorf("an unknown command returned %v, want a usageError", err)
+	}
+
+	got = nil
+	if err := r.Evaluate("echo --help"); err != nil || got != nil {
+		t.Errorf("echo --help ran echo with %q, %v", got, err)
+	}
+}
+
+// No command may panic on being given nothing, or one argument it doesn't expect.
+func TestCommandsWithFewArguments(t *testing.T) {
+	useTestState(t)
+
+	// so a snapshot written without a file name doesn't end up in the source tree
+	dir, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(dir) })
+
+	color := colorEnabled
+	t.Cleanup(func() { colorEnabled = color })
+
+	// questions get no answer, and the network fails, so nothing waits
+	ask = func(string) (string, error) { return "", io.EOF }
+	fakeRetrySleep(t)
+	httpClient = &stubDoer{t: t, respond: func(*http.Request) (*http.Response, error) {
+		return nil, errors.New("no network in tests")
+	}}
+
+	r := NewREPL()
+	registerBuiltins(r)
+
+	arguments := [][]string{nil, {}, {""}, {"x"}, {"*"}, {"0"}, {"-1"}, {"--csv"}, {"--day"}, {"on"}, {"--iso"}, {"--relative"}, {"--from-now"}, {"--for-location"}, {"reset"}}
+
+	for _, command := range r.Commands() {
+		for _, args := range arguments {
+			func() {
+				defer func() {
+					if recovered := recover(); recovered != nil {
+						t.Errorf("%s %q panicked: %v", command.Name, args, recovered)
+					}
+				}()
+				command.Run(args)
+			}()
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+/*
+	Requests that fail in a way that might not happen again, like a dropped connection, a timeout, a 429 or a 5xx, are
+	tried again after a wait that doubles each time, with jitter so that many weths turned away at once don't all come
+	back at once. A Retry-After header is waited out instead, when it's not too long.
+*/
+
+// how many times a request is tried in all, counting the first
+const maxRequestAttempts = 3
+
+// the wait before the first retry, doubled for each one after, and the longest a retry waits either way. Anything
+// asking for longer, like a Retry-After of several minutes, fails now rather than leaving the prompt hanging
+const (
+	firstRetryWait = 500 * time.Millisecond
+	maxRetryWait   = 10 * time.Second
+)
+
+// retrySleep waits d before a retry, or less when ctx is cancelled first, and retryClock is the time a Retry-After
+// date is counted from. Tests replace them so they don't really wait.
+var (
+	retrySleep = func(ctx context.Context, d time.Duration) error {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(d):
+			return nil
+		}
+	}
+	retryClock = time.Now
+)
+
+// doWithRetry sends req through httpClient up to maxAttempts times, waiting between attempts while the failure looks
+// like one that could pass. It stops waiting as soon as ctx is cancelled. A response is returned as soon as its status
+// isn't one worth retrying, or with the last attempt's status, so the caller sees why it failed.
+func doWithRetry(ctx context.Context, req *http.Request, maxAttempts int) (*http.Response, error) {
+
+	client := currentClient()
+
+	for attempt := 1; ; attempt++ {
+
+		resp, err := client.Do(req)
+
+		wait, retry := retryWait(ctx, resp, err, attempt)
+		if !retry || attempt >= maxAttempts || req.Body != nil && req.GetBody == nil {
+			return resp, err
+		}
+
+		if resp != nil {
+			resp.Body.Close()
+		}
+
+		debugLog.Debug("retrying request", "url", redactedURL(req.URL), "attempt", attempt+1, "wait", wait)
+
+		if err := retrySleep(ctx, wait); err != nil {
+			return nil, err
+		}
+
+		if req.GetBody != nil {
+			if req.Body, err = req.GetBody(); err != nil {
+				return nil, err
+			}
+		}
+	}
+}
+
+// retryWait is whether a request that came back with resp or err is worth trying again, and how long to wait first
+// when it is
+func retryWait(ctx context.Context, resp *http.Response, err error, attempt int) (time.Duration, bool) {
+
+	if err != nil {
+		if ctx.Err() != nil || !transientNetworkError(err) {
+			return 0, false
+		}
+		return backoff(attempt), true
+	}
+
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusServiceUnavailable:
+		if wait, ok := retryAfter(resp.Header.Get("Retry-After")); ok {
+			return wait, wait <= maxRetryWait
+		}
+		return backoff(attempt), true
+	case http.StatusInternalServerError, http.StatusBadGateway, http.StatusGatewayTimeout:
+		return backoff(attempt), true
+	}
+	return 0, false
+}
+
+// transientNetworkError is true for failures to reach a server that might go away, but not for a host name that
+// doesn't exist, or weth's own rate limit or snapshot turning the request down
+func transientNetworkError(err error) bool {
+
+	// every error from *http.Client is a *url.Error, which counts as a net.Error itself whatever went wrong
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		err = urlErr.Err
+	}
+
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) && dnsErr.IsNotFound {
+		return false
+	}
+
+	// a connection the server closed partway through is io.EOF
+	var netErr net.Error
+	return errors.As(err, &netErr) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// backoff is how long to wait after attempt failed: firstRetryWait doubled for each attempt before it, then somewhere
+// between half of that and all of it
+func backoff(attempt int) time.Duration {
+	wait := min(firstRetryWait<<(attempt-1), maxRetryWait)
+	return wait/2 + rand.N(wait/2+1)
+}
+
+// retryAfter reads a Retry-After header, which is either a number of seconds or the time to come back at
+func retryAfter(header string) (time.Duration, bool) {
+
+	if header == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(header); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	if at, err := http.ParseTime(header); err == nil {
+		return max(0, at.Sub(retryClock())), true
+	}
+	return 0, false
+}
@@ -0,0 +1,216 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+)
+
+// fakeRetrySleep makes retries wait for nothing, keeping how long each would have waited
+func fakeRetrySleep(t *testing.T) *[]time.Duration {
+	t.Helper()
+
+	var waits []time.Duration
+	sleep := retrySleep
+	retrySleep = func(ctx context.Context, d time.Duration) error {
+		waits = append(waits, d)
+		return ctx.Err()
+	}
+	t.Cleanup(func() { retrySleep = sleep })
+
+	return &waits
+}
+
+// respondInTurn makes httpClient answer with each of statuses in order, repeating the last, with the headers given
+func respondInTurn(t *testing.T, header http.Header, statuses ...int) *stubDoer {
+	t.Helper()
+
+	doer := &stubDoer{t: t}
+	doer.respond = func(*http.Request) (*http.Response, error) {
+		status := statuses[min(len(doer.requests()), len(statuses))-1]
+		resp := stubResponse(status, "")
+		resp.Header = header.Clone()
+		return resp, nil
+	}
+	httpClient = doer
+	return doer
+}
+
+func retryRequest(t *testing.T, ctx context.Context) *http.Request {
+	t.Helper()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "https://api.open-meteo.com/v1/forecast", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return req
+}
+
+func TestBackoffGrows(t *testing.T) {
+
+	for attempt := 1; attempt <= 8; attempt++ {
+		longest := min(firstRetryWait<<(attempt-1), maxRetryWait)
+		for range 20 {
+			if wait := backoff(attempt); wait < longest/2 || wait > longest {
+				t.Fatalf("backoff(%d) = %v, want between %v and %v", attempt, wait, longest/2, longest)
+			}
+		}
+	}
+}
+
+func TestRetryUntilItWorks(t *testing.T) {
+	useTestState(t)
+	waits := fakeRetrySleep(t)
+	doer := respondInTurn(t, nil, http.StatusServiceUnavailable, http.StatusBadGateway, http.StatusOK)
+
+	resp, err := doWithRetry(context.Background(), retryRequest(t, context.Background()), 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("status = %d, want 200", resp.StatusCode)
+	}
+
+	if len(doer.requests()) != 3 || len(*waits) != 2 {
+		t.Fatalf("%d requests with waits %v, want 3 requests and 2 waits", len(doer.requests()), *waits)
+	}
+
+	// the second wait is for the second attempt, so it can be up to twice the first
+	if first, second := (*waits)[0], (*waits)[1]; first > firstRetryWait || second < firstRetryWait/2 || second > 2*firstRetryWait {
+		t.Errorf("waited %v then %v", first, second)
+	}
+}
+
+func TestRetryGivesUpAfterMaxAttempts(t *testing.T) {
+	useTestState(t)
+	waits := fakeRetrySleep(t)
+	doer := respondInTurn(t, nil, http.StatusInternalServerError)
+
+	resp, err := doWithRetry(context.Background(), retryRequest(t, context.Background()), maxRequestAttempts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the last attempt's response comes back, so the caller sees why it failed
+	if resp.StatusCode != http.StatusInternalServerError {
+		t.Errorf("status = %d, want 500", resp.StatusCode)
+	}
+	if len(doer.requests()) != maxRequestAttempts || len(*waits) != maxRequestAttempts-1 {
+		t.Errorf("%d requests with %d waits, want %d and %d", len(doer.requests()), len(*waits), maxRequestAttempts, maxRequestAttempts-1)
+	}
+}
+
+func TestRetryNotForClientErrors(t *testing.T) {
+	useTestState(t)
+	fakeRetrySleep(t)
+	doer := respondInTurn(t, nil, http.StatusNotFound)
+
+	if resp, err := doWithRetry(context.Background(), retryRequest(t, context.Background()), 3); err != nil || resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("got %v, %v, want the 404", resp, err)
+	}
+	if len(doer.requests()) != 1 {
+		t.Errorf("sent %d requests for a 404, want 1", len(doer.requests()))
+	}
+}
+
+func TestRetryAfterIsWaitedOut(t *testing.T) {
+	useTestState(t)
+	waits := fakeRetrySleep(t)
+	respondInTurn(t, http.Header{"Retry-After": {"3"}}, http.StatusTooManyRequests, http.StatusOK)
+
+	if _, err := doWithRetry(context.Background(), retryRequest(t, context.Background()), 3); err != nil {
+		t.Fatal(err)
+	}
+	if len(*waits) != 1 || (*waits)[0] != 3*time.Second {
+		t.Errorf("waited %v, want 3s", *waits)
+	}
+}
+
+func TestRetryAfterDate(t *testing.T) {
+
+	now := time.Date(2024, time.June, 3, 12, 0, 0, 0, time.UTC)
+	clock := retryClock
+	retryClock = func() time.Time { return now }
+	t.Cleanup(func() { retryClock = clock })
+
+	tests := []struct {
+		header string
+		wait   time.Duration
+		ok     bool
+	}{
+		{"", 0, false},
+		{"7", 7 * time.Second, true},
+		{"-1", 0, false},
+		{"soon", 0, false},
+		{now.Add(90 * time.Second).Format(http.TimeFormat), 90 * time.Second, true},
+
+		// a date that's already gone is no wait at all
+		{now.Add(-time.Hour).Format(http.TimeFormat), 0, true},
+	}
+
+	for _, test := range tests {
+		if wait, ok := retryAfter(test.header); wait != test.wait || ok != test.ok {
+			t.Errorf("retryAfter(%q) = %v, %v, want %v, %v", test.header, wait, ok, test.wait, test.ok)
+		}
+	}
+}
+
+func TestRetryAfterTooLongFailsNow(t *testing.T) {
+	useTestState(t)
+	waits := fakeRetrySleep(t)
+	doer := respondInTurn(t, http.Header{"Retry-After": {"600"}}, http.StatusTooManyRequests)
+
+	resp, err := doWithRetry(context.Background(), retryRequest(t, context.Background()), 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusTooManyRequests || len(doer.requests()) != 1 || len(*waits) != 0 {
+		t.Errorf("status %d after %d requests and waits %v, want the 429 straight away", resp.StatusCode, len(doer.requests()), *waits)
+	}
+}
+
+func TestRetryStopsWhenCancelled(t *testing.T) {
+	useTestState(t)
+	fakeRetrySleep(t)
+	doer := respondInTurn(t, nil, http.StatusServiceUnavailable)
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	// cancelled while waiting for the first retry, the way an interrupt does it
+	sleep := retrySleep
+	retrySleep = func(ctx context.Context, d time.Duration) error {
+		cancel()
+		return sleep(ctx, d)
+	}
+
+	if _, err := doWithRetry(ctx, retryRequest(t, ctx), 3); !errors.Is(err, context.Canceled) {
+		t.Errorf("err = %v, want context.Canceled", err)
+	}
+	if len(doer.requests()) != 1 {
+		t.Errorf("sent %d requests, want 1", len(doer.requests()))
+	}
+}
+
+func TestRetryableNetworkErrors(t *testing.T) {
+
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"timeout", &url.Error{Op: "Get", Err: &net.OpError{Op: "dial", Err: errors.New("i/o timeout")}}, true},
+		{"connection closed", &url.Error{Op: "Get", Err: io.EOF}, true},
+		{"no such host", &url.Error{Op: "Get", Err: &net.DNSError{Err: "no such host", IsNotFound: true}}, false},
+		{"rate limited", errRateLimited, false},
+	}
+
+	for _, test := range tests {
+		if got := transientNetworkError(test.err); got != test.want {
+			t.Errorf("%s: transientNetworkError = %v, want %v", test.name, got, test.want)
+		}
+	}
+}
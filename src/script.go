@@ -0,0 +1,82 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+)
+
+// RunScript executes each line of the file at path as if it had been typed into the REPL. With no path, the lines
+// are read from stdin. Blank lines and lines starting with '#' are skipped, and an 'exit' line ends the script early.
+// It returns the exit status: 0 when every command succeeded, 2 when any was used wrong or isn't a command, and 1
+// when any failed otherwise. With failFast, the first command that fails ends the script.
+func (r *REPL) RunScript(path string, failFast bool) int {
+
+	var input io.Reader = os.Stdin
+
+	if path != "" {
+		file, err := os.Open(path)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer file.Close()
+		input = file
+	}
+
+	scanner := bufio.NewScanner(input)
+
+	// questions are answered by the script's next line, echoed so the output reads like the interactive session
+	ask = func(question string) (string, error) {
+		fmt.Print(question)
+		if !scanner.Scan() {
+			fmt.Println()
+			return "", io.EOF
+		}
+		fmt.Println(scanner.Text())
+		return scanner.Text(), nil
+	}
+
+	status := 0
+
+	for scanner.Scan() {
+
+		// the scanner takes the \r off a Windows line ending, but not spaces or tabs around the command
+		line := strings.TrimSpace(scanner.Text())
+
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if line == "exit" {
+			return status
+		}
+
+		err := r.Evaluate(line)
+
+		if interrupted.Load() {
+			exitInterrupted()
+		}
+
+		if err != nil {
+			if errors.As(err, new(usageError)) {
+				status = 2
+			} else {
+				status = max(status, 1)
+			}
+
+			if failFast {
+				return status
+			}
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		log.Fatal(err)
+	}
+
+	return status
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// runScript runs lines as a script file through a REPL with the builtins, returning its exit status
+func runScript(t *testing.T, lines string, failFast bool) int {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "script.weth")
+	if err := os.WriteFile(path, []byte(lines), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	r := NewREPL()
+	registerBuiltins(r)
+	return r.RunScript(path, failFast)
+}
+
+func TestScriptExitStatus(t *testing.T) {
+	useTestState(t)
+
+	tests := []struct {
+		name     string
+		lines    string
+		failFast bool
+		status   int
+	}{
+		{"every command succeeds", "now\nhours 2\n", false, 0},
+		{"a command fails", "now\ndays 99999\nnow\n", false, 1},
+		{"a command is used wrong", "now\nthreshold\n", false, 2},
+		{"not a command", "nosuch\n", false, 2},
+		{"used wrong after failing", "days 99999\nthreshold\n", false, 2},
+		{"failing after used wrong", "threshold\ndays 99999\n", false, 2},
+		{"fail fast stops at the first", "days 99999\nthreshold\n", true, 1},
+		{"exit ends it first", "now\nexit\nthreshold\n", false, 0},
+	}
+
+	for _, test := range tests {
+		if status := runScript(t, test.lines, test.failFast); status != test.status {
+			t.Errorf("%s: exit status %d, want %d", test.name, status, test.status)
+		}
+	}
+}
+
+func TestScriptLineEndings(t *testing.T) {
+	useTestState(t)
+
+	var lines [][]string
+	r := NewREPL()
+	r.Register(Command{Name: "echo", Run: func(args []string) (string, error) {
+		lines = append(lines, args)
+		return "", nil
+	}})
+	r.Register(Command{Name: "question", Run: func([]string) (string, error) {
+		answer, err := ask("? ")
+		lines = append(lines, []string{"answered", answer})
+		return "", err
+	}})
+
+	path := filepath.Join(t.TempDir(), "windows.weth")
+	script := "echo a b\r\n\r\n# a comment\r\necho\tc\t\td\r\n  \t\r\nquestion\r\nyes\r\necho e  \r\n"
+	if err := os.WriteFile(path, []byte(script), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if status := r.RunScript(path, false); status != 0 {
+		t.Errorf("exit status %d, want 0", status)
+	}
+
+	// \r and tabs don't end up in arguments, blank and comment lines are skipped, and a question takes the next line
+	want := [][]string{{"a", "b"}, {"c", "d"}, {"answered", "yes"}, {"e"}}
+	if len(lines) != len(want) {
+		t.Fatalf("commands got %q, want %q", lines, want)
+	}
+	for i := range want {
+		if !slices.Equal(lines[i], want[i]) {
+			t.Errorf("command %d got %q, want %q", i+1, lines[i], want[i])
+		}
+	}
+}
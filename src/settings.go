@@ -0,0 +1,135 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// setting is a value in config that the config command can change while weth is running. It's either a number
+// from Minimum to Maximum, one of Choices, or a Temperature, which is kept in °C and shown and typed in the units
+// temperatures are shown in.
+type setting struct {
+	Name    string
+	Help    string
+	Value   *int
+	Minimum int
+	Maximum int
+
+	Choice  *string
+	Choices []string
+
+	Temperature *float64
+}
+
+// settings are listed by config in this order
+var settings = []setting{
+	{Name: "hours-default", Help: "hours of forecast shown by hours with no count", Value: &config.HoursDefault, Minimum: 0, Maximum: 384},
+	{Name: "days-default", Help: "days of forecast shown by days with no count", Value: &config.DaysDefault, Minimum: 1, Maximum: 16},
+	{Name: "temperature-decimals", Help: "decimal places temperatures are shown with, 0 or 1", Value: &config.TemperatureDecimals, Minimum: 0, Maximum: 1},
+	{Name: "comfort-temp", Help: "the temperature now --relative and hours --relative count from, like 21C or 70F", Temperature: &config.ComfortTemperature},
+	{Name: "hour-snapping", Help: "the forecast hour used for a TIME between hours: nearest, or floor for the hour it's in", Choice: &config.HourSnapping, Choices: []string{"nearest", "floor"}},
+	{Name: "relative-anchor", Help: "what settime /N, advance, rewind and diff +N count from: time for TIME, or now for the real current time", Choice: &config.RelativeAnchor, Choices: []string{"time", "now"}},
+	{Name: "prompt-weather", Help: "show the temperature at TIME in the prompt, the terminal's title, both, or off", Choice: &config.PromptWeather, Choices: []string{"off", "prompt", "title", "both"}},
+	{Name: "empty-line", Help: "what Enter on an empty line does: skip it, or repeat the last command", Choice: &config.EmptyLine, Choices: []string{"skip", "repeat"}},
+	{Name: "wind-alert", Help: "tag hours and days windy when the wind is over this, in mph or km/h as shown. 0 turns it off", Value: &config.WindAlert, Minimum: 0, Maximum: 300},
+	{Name: "coordinate-decimals", Help: "decimal places coordinates are shown, saved and shared with. 2 is within about a kilometer", Value: &config.CoordinateDecimals, Minimum: 0, Maximum: 6},
+	{Name: "cache-ttl-minutes", Help: "how long weather is reused before fetching it again. 0 turns caching off", Value: &config.CacheTTLMinutes, Minimum: 0, Maximum: 24 * 60},
+	{Name: "background-refresh-minutes", Help: "fetch the current weather again this often while the REPL is open, so now is instant. Keep it under cache-ttl-minutes. 0 turns it off", Value: &config.BackgroundRefreshMinutes, Minimum: 0, Maximum: 24 * 60},
+	{Name: "location-max-age-hours", Help: "how long the location found from your IP address is reused between runs", Value: &config.LocationMaxAgeHours, Minimum: 0, Maximum: 24 * 30},
+}
+
+func lookupSetting(name string) (setting, bool) {
+	for _, s := range settings {
+		if s.Name == name {
+			return s, true
+		}
+	}
+	return setting{}, false
+}
+
+func settingNames() []string {
+	var names []string
+	for _, s := range settings {
+		names = append(names, s.Name)
+	}
+	return names
+}
+
+func (s setting) String() string {
+	if s.Choice != nil {
+		return *s.Choice
+	}
+	if s.Temperature != nil {
+		return formatTemperature(*s.Temperature)
+	}
+	return strconv.Itoa(*s.Value)
+}
+
+// set changes the setting to text, if it's allowed
+func (s setting) set(text string) error {
+
+	if s.Choice != nil {
+		if !slices.Contains(s.Choices, text) {
+			return fmt.Errorf("%s has to be one of %s, got %s", s.Name, strings.Join(s.Choices, ", "), text)
+		}
+		*s.Choice = text
+		return nil
+	}
+
+	if s.Temperature != nil {
+		celsius, err := parseTemperature(text)
+		if err != nil {
+			return err
+		}
+		*s.Temperature = celsius
+		return nil
+	}
+
+	value, err := strconv.Atoi(text)
+	if err != nil || value < s.Minimum || value > s.Maximum {
+		return fmt.Errorf("%s has to be a whole number from %d to %d, got %s", s.Name, s.Minimum, s.Maximum, text)
+	}
+	*s.Value = value
+	return nil
+}
+
+func setConfig(args []string) (string, error) {
+
+	if len(args) == 0 {
+		nameWidth, valueWidth := 0, 0
+		for _, s := range settings {
+			nameWidth = max(nameWidth, len(s.Name))
+			valueWidth = max(valueWidth, len(s.String()))
+		}
+
+		var lines []string
+		for _, s := range settings {
+			lines = append(lines, fmt.Sprintf("%-*s  %-*s  %s", nameWidth, s.Name, valueWidth, s, s.Help))
+		}
+		return strings.Join(lines, "\n  "), nil
+	}
+
+	s, ok := lookupSetting(args[0])
+	if !ok {
+		return "", errors.New("no setting named " + args[0] + ". Settings are " + strings.Join(settingNames(), ", "))
+	}
+
+	if len(args) == 1 {
+		return fmt.Sprintf("%s: %s", s.Name, s), nil
+	}
+
+	var err error
+	updateState(func() { err = s.set(args[1]) })
+	if err != nil {
+		return "", err
+	}
+
+	if err := saveConfig(); err != nil {
+		return fmt.Sprintf("%s set to %s, but could not be saved: %v", s.Name, s, err), nil
+	}
+
+	return fmt.Sprintf("%s set to %s", s.Name, s), nil
+}
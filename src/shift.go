@@ -0,0 +1,123 @@
+package main
+
+import (
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// dayPattern finds the days in a duration like 1d12h, which time.ParseDuration doesn't know about
+var dayPattern = regexp.MustCompile(`(\d+(\.\d+)?)d`)
+
+// parseShift parses a Go duration like 3h or 90m, where d can also be used for 24 hours.
+func parseShift(text string) (time.Duration, error) {
+
+	expanded := dayPattern.ReplaceAllStringFunc(text, func(match string) string {
+		days, _ := strconv.ParseFloat(match[:len(match)-1], 64)
+		return strconv.FormatFloat(days*24, 'f', -1, 64) + "h"
+	})
+
+	shift, err := time.ParseDuration(expanded)
+	if err != nil {
+		return 0, errors.New("not a duration: " + text + ". Use a number and a unit, e.g. 3h, 2d or 90m")
+	}
+
+	return shift, nil
+}
+
+// anchorFlag takes --from-now or --from-time out of args, and returns the time relative values count from: the real
+// current time, or TIME. Without either flag, config relative-anchor decides.
+func anchorFlag(args []string) ([]string, time.Time) {
+
+	fromNow := config.RelativeAnchor == "now"
+
+	var rest []string
+	for _, arg := range args {
+		switch arg {
+		case "--from-now":
+			fromNow = true
+		case "--from-time":
+			fromNow = false
+		default:
+			rest = append(rest, arg)
+		}
+	}
+
+	if fromNow {
+		return rest, time.Now().In(displayZone())
+	}
+	return rest, internalTime
+}
+
+// anchorNote is the usage note for commands with relative values, saying what they count from
+func anchorNote() string {
+	if config.RelativeAnchor == "now" {
+		return tr("relative values count from the real current time, or from TIME with --from-time")
+	}
+	return tr("relative values count from TIME, or from the real current time with --from-now")
+}
+
+// parseTimeReference reads a time as now, a shift from base like +4h or -1d, a time of day on base's date like 5pm or
+// 17:30, or an ISO timestamp. now is base itself.
+func parseTimeReference(text string, base time.Time) (time.Time, error) {
+
+	if text == "now" {
+		return base, nil
+	}
+
+	if strings.HasPrefix(text, "+") || strings.HasPrefix(text, "-") {
+		shift, err := parseShift(text[1:])
+		if err != nil {
+			return time.Time{}, err
+		}
+		if text[0] == '-' {
+			shift = -shift
+		}
+		return base.Add(shift), nil
+	}
+
+	if strings.Contains(text, "T") {
+		parsed, err := parseISOTime(text)
+		if err != nil {
+			return time.Time{}, errors.New("Expected a timestamp like 2024-06-03T14:30:00, got " + text)
+		}
+		return parsed, nil
+	}
+
+	hour, minute, err := parseClock(text)
+	if err != nil {
+		return time.Time{}, errors.New("Expected now, a shift like +4h, a time like 5pm, or a timestamp, got " + text)
+	}
+
+	return time.Date(base.Year(), base.Month(), base.Day(), hour, minute, 0, 0, base.Location()), nil
+}
+
+// shiftTime moves TIME by the duration in args, backwards when direction is -1. With --from-now, it's moved from the
+// real current time instead.
+func shiftTime(command string, args []string, direction time.Duration) (string, error) {
+
+	args, base := anchorFlag(args)
+
+	if len(args) != 1 {
+		return "", errUsage(command)
+	}
+
+	shift, err := parseShift(args[0])
+	if err != nil {
+		return "", err
+	}
+
+	setInternalTime(base.Add(direction * shift).In(displayZone()))
+
+	return "  set time to: " + printTime() + " " + relativeToNow(internalTime), nil
+}
+
+func advanceTime(args []string) (string, error) {
+	return shiftTime("advance", args, 1)
+}
+
+func rewindTime(args []string) (string, error) {
+	return shiftTime("rewind", args, -1)
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseShift(t *testing.T) {
+
+	tests := []struct {
+		text string
+		want time.Duration
+	}{
+		{"3h", 3 * time.Hour},
+		{"90m", 90 * time.Minute},
+		{"2d", 48 * time.Hour},
+		{"1d12h", 36 * time.Hour},
+		{"1.5d", 36 * time.Hour},
+	}
+
+	for _, test := range tests {
+		if got, err := parseShift(test.text); err != nil || got != test.want {
+			t.Errorf("parseShift(%q) = %v, %v, want %v", test.text, got, err, test.want)
+		}
+	}
+
+	for _, text := range []string{"", "3", "d", "3 hours"} {
+		if _, err := parseShift(text); err == nil {
+			t.Errorf("parseShift(%q) succeeded", text)
+		}
+	}
+}
+
+func TestAnchorFlag(t *testing.T) {
+	useTestState(t)
+	internalTime = time.Now().Add(-72 * time.Hour)
+
+	tests := []struct {
+		anchor  string
+		args    []string
+		fromNow bool
+		rest    int
+	}{
+		{"time", []string{"5"}, false, 1},
+		{"now", []string{"5"}, true, 1},
+		{"time", []string{"--from-now", "5"}, true, 1},
+		{"now", []string{"5", "--from-time"}, false, 1},
+		{"time", []string{"--from-now", "--from-time"}, false, 0},
+		{"time", nil, false, 0},
+	}
+
+	for _, test := range tests {
+		config.RelativeAnchor = test.anchor
+		rest, base := anchorFlag(test.args)
+
+		if len(rest) != test.rest {
+			t.Errorf("anchorFlag(%q) with anchor %s left %q", test.args, test.anchor, rest)
+		}
+		if fromNow := time.Since(base).Abs() < time.Minute; fromNow != test.fromNow {
+			t.Errorf("anchorFlag(%q) with anchor %s counts from %v", test.args, test.anchor, base)
+		}
+	}
+}
+
+func TestParseTimeReference(t *testing.T) {
+	useTestState(t)
+
+	base := time.Date(2024, time.June, 3, 14, 30, 0, 0, displayZone())
+
+	tests := []struct {
+		text string
+		want time.Time
+	}{
+		{"now", base},
+		{"+4h", base.Add(4 * time.Hour)},
+		{"-1d", base.Add(-24 * time.Hour)},
+		{"+1d6h", base.Add(30 * time.Hour)},
+		{"5pm", time.Date(2024, time.June, 3, 17, 0, 0, 0, displayZone())},
+		{"9am", time.Date(2024, time.June, 3, 9, 0, 0, 0, displayZone())},
+		{"17:45", time.Date(2024, time.June, 3, 17, 45, 0, 0, displayZone())},
+		{"2024-12-25T08:00:00", time.Date(2024, time.December, 25, 8, 0, 0, 0, displayZone())},
+		{"2024-12-25T08:00:00Z", time.Date(2024, time.December, 25, 8, 0, 0, 0, time.UTC)},
+	}
+
+	for _, test := range tests {
+		if got, err := parseTimeReference(test.text, base); err != nil || !got.Equal(test.want) {
+			t.Errorf("parseTimeReference(%q) = %v, %v, want %v", test.text, got, err, test.want)
+		}
+	}
+
+	for _, text := range []string{"", "later", "+", "+4x", "2024-13-01T00:00:00", "25:00"} {
+		if _, err := parseTimeReference(text, base); err == nil {
+			t.Errorf("parseTimeReference(%q) succeeded", text)
+		}
+	}
+}
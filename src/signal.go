@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+// requestContext is cancelled when weth is interrupted, which aborts any request still waiting on the network.
+var requestContext, cancelRequests = context.WithCancel(context.Background())
+
+// interrupted is set by the first interrupt. The REPL and scripts check it after each command, and exit rather than
+// go on to the next.
+var interrupted atomic.Bool
+
+// how long a command has to finish after the first interrupt before weth exits without it. A second interrupt in
+// that time exits at once, without saving anything
+const forceExitWindow = 2 * time.Second
+
+// handleInterrupts exits cleanly on Ctrl-C or SIGTERM. At the prompt the terminal is in raw mode and Ctrl-C ends the
+// line reader like Ctrl-D does, so this is what catches interrupts while a command is running. The first one cancels
+// the command's requests, so it fails straight away and weth exits once it has returned.
+func handleInterrupts() {
+
+	signals := make(chan os.Signal, 2)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+
+	go func() {
+
+		nextInterrupt(signals)
+		interrupted.Store(true)
+		cancelRequests()
+
+		again := make(chan struct{})
+		go func() {
+			nextInterrupt(signals)
+			close(again)
+		}()
+
+		select {
+		case <-again:
+			os.Exit(1)
+		case <-time.After(forceExitWindow):
+			exitInterrupted()
+		}
+	}()
+}
+
+// nextInterrupt waits for a signal that should interrupt weth. Ctrl-C goes to the pager too, since it's in the same
+// process group, so while the pager is open it's left for the pager to handle.
+func nextInterrupt(signals <-chan os.Signal) os.Signal {
+	for {
+		if sig := <-signals; sig != os.Interrupt || !paging.Load() {
+			return sig
+		}
+	}
+}
+
+// exitOnce makes sure only one of the REPL and handleInterrupts saves the history and exits, when both get there at
+// once
+var exitOnce sync.Once
+
+// exitInterrupted saves the history and exits with the status a shell gives a command ended by Ctrl-C
+func exitInterrupted() {
+	exitOnce.Do(func() {
+		fmt.Println()
+		saveHistory()
+		os.Exit(130)
+	})
+}
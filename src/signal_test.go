@@ -0,0 +1,29 @@
+package main
+
+import (
+	"os"
+	"syscall"
+	"testing"
+)
+
+func TestNextInterruptWhilePaging(t *testing.T) {
+
+	signals := make(chan os.Signal, 3)
+
+	paging.Store(true)
+	t.Cleanup(func() { paging.Store(false) })
+
+	// Ctrl-C is the pager's while it's open, but SIGTERM still isn't
+	signals <- os.Interrupt
+	signals <- os.Interrupt
+	signals <- syscall.SIGTERM
+	if sig := nextInterrupt(signals); sig != syscall.SIGTERM || len(signals) != 0 {
+		t.Errorf("nextInterrupt while paging = %v with %d left, want SIGTERM after both Ctrl-Cs", sig, len(signals))
+	}
+
+	paging.Store(false)
+	signals <- os.Interrupt
+	if sig := nextInterrupt(signals); sig != os.Interrupt {
+		t.Errorf("nextInterrupt = %v, want Ctrl-C", sig)
+	}
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"sync"
+	"time"
+)
+
+/*
+	snapshot writes TIME, LOCATION, the display settings, and the responses weth has had from the network to a JSON
+	file, for bug reports. load-snapshot puts all of that back and answers requests from the recorded responses
+	instead of the network, so the same commands show what the snapshot's maker saw.
+*/
+
+// the most responses a snapshot keeps, the most recent ones
+const maxRecordedResponses = 50
+
+// the IP lookups are left out of snapshots, since they'd give away the address the snapshot was made from
+var unrecordedHosts = []string{"api64.ipify.org", "ip-api.com"}
+
+type recordedResponse struct {
+	URL  string          `json:"url"`
+	Body json.RawMessage `json:"body"`
+}
+
+var recordedResponses []recordedResponse
+var recordMu sync.Mutex
+
+// recordResponse keeps body for snapshots, with any API key taken out of address. Bodies that aren't JSON are
+// skipped, which says they're not weather.
+func recordResponse(address string, body []byte) {
+
+	u, err := url.Parse(address)
+	if err != nil || slices.Contains(unrecordedHosts, u.Hostname()) || !json.Valid(body) {
+		return
+	}
+
+	recordMu.Lock()
+	defer recordMu.Unlock()
+
+	recordedResponses = append(recordedResponses, recordedResponse{URL: redactedURL(u), Body: slices.Clone(body)})
+	if len(recordedResponses) > maxRecordedResponses {
+		recordedResponses = recordedResponses[len(recordedResponses)-maxRecordedResponses:]
+	}
+}
+
+type snapshotFile struct {
+	Created  time.Time `json:"created"`
+	Location Location  `json:"location"`
+	Time     time.Time `json:"time"`
+	Units    string    `json:"units"`
+	Military bool      `json:"military"`
+	Language string    `json:"language"`
+	Provider string    `json:"provider"`
+	Offline  bool      `json:"offline"`
+
+	// a provider with an API key can send its requests somewhere else, so the replay has to have one too
+	HasAPIKey bool `json:"has_api_key"`
+
+	Responses []recordedResponse `json:"responses"`
+}
+
+func snapshot(args []string) (string, error) {
+
+	if len(args) != 1 {
+		return "", errUsage("snapshot")
+	}
+
+	recordMu.Lock()
+	responses := slices.Clone(recordedResponses)
+	recordMu.Unlock()
+
+	state := snapshotFile{
+		Created:  time.Now(),
+		Location: internalLocation.coarsened(),
+		Time:     internalTime,
+		Units:    activeUnits(),
+		Military: militaryTime,
+		Language: language,
+		Provider: config.Provider,
+		Offline:  offline,
+
+		HasAPIKey: apiKey() != "",
+
+		Responses: responses,
+	}
+
+	body, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return "", err
+	}
+
+	if err := os.WriteFile(args[0], append(body, '\n'), 0o644); err != nil {
+		return "", errors.New("could not write the snapshot: " + err.Error())
+	}
+
+	return "  " + tr("snapshot written to ") + args[0] + ", " + plural(len(responses), "response"), nil
+}
+
+// replayDoer answers requests with the responses recorded in a snapshot, and fails any it doesn't have.
+type replayDoer struct {
+	responses map[string][]byte
+}
+
+func (r replayDoer) Do(req *http.Request) (*http.Response, error) {
+
+	address := redactedURL(req.URL)
+
+	body, ok := r.responses[address]
+	if !ok {
+		return nil, errors.New("the snapshot has no response for " + address)
+	}
+
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": {"application/json"}},
+		Body:       io.NopCloser(bytes.NewReader(body)),
+		Request:    req,
+	}, nil
+}
+
+func loadSnapshot(args []string) (string, error) {
+
+	if len(args) != 1 {
+		return "", errUsage("load-snapshot")
+	}
+
+	body, err := os.ReadFile(args[0])
+	if err != nil {
+		return "", errors.New("could not read the snapshot: " + err.Error())
+	}
+
+	var state snapshotFile
+	if err := json.Unmarshal(body, &state); err != nil {
+		return "", errors.New(args[0] + " isn't a snapshot: " + err.Error())
+	}
+
+	constructor, ok := providers[state.Provider]
+	if !ok && !state.Offline {
+		return "", errors.New("the snapshot was made with the " + state.Provider + " provider, which this weth doesn't have")
+	}
+
+	// later responses for the same request win, as they did when the snapshot was made
+	responses := map[string][]byte{}
+	for _, response := range state.Responses {
+		responses[response.URL] = response.Body
+	}
+	replayed := withCache(newFakeProvider(""))
+	if !state.Offline {
+		// the key is never sent anywhere, and replayDoer takes it out of requests before looking them up
+		key := ""
+		if state.HasAPIKey {
+			key = "REDACTED"
+		}
+		replayed = withCache(constructor(key))
+	}
+
+	updateState(func() {
+		httpClient = replayDoer{responses}
+		offline = state.Offline
+		provider = replayed
+
+		internalLocation = state.Location
+		locationSource = tr("loaded from a snapshot")
+		snapshotUnits = state.Units
+		militaryTime = state.Military
+		if _, ok := monthNames[state.Language]; ok {
+			language = state.Language
+		}
+		internalTime = state.Time.In(displayZone())
+	})
+	clearCache()
+
+	return "  " + tr("loaded the snapshot from ") + state.Created.In(displayZone()).Format(time.DateTime) + ": " + printTime() + ", " +
+		internalLocation.City + "\n  " + tr("requests are answered from the snapshot until weth is restarted"), nil
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadSnapshotKeepsConfigUnits(t *testing.T) {
+	useTestState(t)
+
+	savedSource := locationSource
+	t.Cleanup(func() { locationSource = savedSource })
+
+	// the snapshot is made where LOCATION has units of its own
+	config.LocationUnits[locationKey(internalLocation)] = "imperial"
+	if err := saveConfig(); err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "snapshot.json")
+	if _, err := snapshot([]string{path}); err != nil {
+		t.Fatal(err)
+	}
+
+	delete(config.LocationUnits, locationKey(internalLocation))
+	if _, err := loadSnapshot([]string{path}); err != nil {
+		t.Fatal(err)
+	}
+	if units := activeUnits(); units != "imperial" {
+		t.Errorf("units after loading the snapshot are %s, want the snapshot's imperial", units)
+	}
+
+	if err := saveConfig(); err != nil {
+		t.Fatal(err)
+	}
+	configFile, _ := configPath()
+	body, err := os.ReadFile(configFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var saved Config
+	if err := json.Unmarshal(body, &saved); err != nil {
+		t.Fatal(err)
+	}
+	if saved.Units != "metric" || len(saved.LocationUnits) != 0 {
+		t.Errorf("the config file has units %q and %v after loading a snapshot, want metric and none", saved.Units, saved.LocationUnits)
+	}
+
+	// choosing units goes back to the config's
+	if _, err := setUnits([]string{"both"}); err != nil {
+		t.Fatal(err)
+	}
+	if units := activeUnits(); units != "both" {
+		t.Errorf("units after units both are %s", units)
+	}
+}
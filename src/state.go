@@ -0,0 +1,36 @@
+package main
+
+import (
+	"sync"
+	"time"
+)
+
+/*
+	Commands run one at a time on the REPL's goroutine, and they're the only thing that changes internalTime,
+	internalLocation, the provider and the settings. They make those changes through updateState, which holds stateMu
+	for writing just while the values are assigned, and anything running alongside the REPL takes it for reading to
+	copy what it needs, so it never sees a change half made. The REPL's goroutine reads them without the lock, since
+	nothing else writes them. stateMu is never held while waiting on the network or for input, and nothing that takes
+	it may be called from inside updateState.
+*/
+
+var stateMu sync.RWMutex
+
+// updateState makes change to the shared state while holding stateMu. change should only assign.
+func updateState(change func()) {
+	stateMu.Lock()
+	defer stateMu.Unlock()
+	change()
+}
+
+// setInternalTime makes t TIME
+func setInternalTime(t time.Time) {
+	updateState(func() { internalTime = t })
+}
+
+// currentState is a consistent view of TIME and LOCATION for code running outside of a command.
+func currentState() (time.Time, Location) {
+	stateMu.RLock()
+	defer stateMu.RUnlock()
+	return internalTime, internalLocation
+}
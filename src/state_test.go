@@ -0,0 +1,63 @@
+package main
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+// Run with -race: commands moving TIME and LOCATION while another goroutine reads them, the way the background
+// refresh does, have to be clean.
+func TestStateReadWhileCommandsRun(t *testing.T) {
+	useTestState(t)
+
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-done:
+				return
+			default:
+			}
+
+			when, loc := currentState()
+			if when.IsZero() || loc.City == "" {
+				t.Errorf("read TIME %v and LOCATION %+v", when, loc)
+				return
+			}
+		}
+	}()
+
+	commands := []struct {
+		run  func([]string) (string, error)
+		args []string
+	}{
+		{setLocation, []string{"Paris", "*", "France"}},
+		{advanceTime, []string{"3h"}},
+		{setTimezone, []string{"UTC"}},
+		{setTime, []string{"9", "3", "6", "2024"}},
+		{setTimezone, []string{"reset"}},
+		{setLocation, []string{"Kyoto", "*", "Japan"}},
+		{resetTime, nil},
+		{setOffline, []string{"on"}},
+	}
+
+	for i := 0; i < 50; i++ {
+		for _, command := range commands {
+			if _, err := command.run(command.args); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	close(done)
+	wg.Wait()
+
+	if when, loc := currentState(); loc.City != "Kyoto" || time.Since(when).Abs() > time.Minute {
+		t.Errorf("ended at %v in %s, want now in Kyoto", when, loc.City)
+	}
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"os"
+	"strings"
+	"unicode"
+
+	"golang.org/x/term"
+)
+
+/*
+	Listings like hours, days and forecast are laid out as tables: each column is as wide as its widest cell, and
+	when that makes the table wider than the terminal, the column marked Flex is cut short to fit.
+*/
+
+// symbols below U+1F000 that are shown as two column emoji even without a variation selector, like ⛅
+var wideSymbols = [...][2]rune{
+	{0x231A, 0x231B}, {0x23E9, 0x23EC}, {0x23F0, 0x23F0}, {0x23F3, 0x23F3}, {0x25FD, 0x25FE}, {0x2614, 0x2615},
+	{0x2648, 0x2653}, {0x267F, 0x267F}, {0x2693, 0x2693}, {0x26A1, 0x26A1}, {0x26AA, 0x26AB}, {0x26BD, 0x26BE},
+	{0x26C4, 0x26C5}, {0x26CE, 0x26CE}, {0x26D4, 0x26D4}, {0x26EA, 0x26EA}, {0x26F2, 0x26F3}, {0x26F5, 0x26F5},
+	{0x26FA, 0x26FA}, {0x26FD, 0x26FD}, {0x2705, 0x2705}, {0x270A, 0x270B}, {0x2728, 0x2728}, {0x274C, 0x274C},
+	{0x274E, 0x274E}, {0x2753, 0x2755}, {0x2757, 0x2757}, {0x2795, 0x2797}, {0x27B0, 0x27B0}, {0x27BF, 0x27BF},
+	{0x2B1B, 0x2B1C}, {0x2B50, 0x2B50}, {0x2B55, 0x2B55},
+}
+
+func isWideSymbol(r rune) bool {
+	for _, span := range wideSymbols {
+		if r >= span[0] && r <= span[1] {
+			return true
+		}
+	}
+	return false
+}
+
+// displayWidth is how many terminal columns s takes up. Most emoji take two, and the variation selectors and
+// joiners that modify them take none.
+func displayWidth(s string) int {
+
+	width := 0
+	runes := []rune(s)
+
+	for i, r := range runes {
+		switch {
+		case r == 0xFE0F:
+			// asks for the emoji form of the previous character, which widens it from one column to two
+			if i > 0 && runes[i-1] < 0x1F000 && !isWideSymbol(runes[i-1]) {
+				width++
+			}
+		case r == 0x200D || unicode.Is(unicode.Mn, r) || (r >= 0xFE00 && r <= 0xFE0E):
+		case r >= 0x1F000 && r <= 0x1FAFF, isWideSymbol(r), r >= 0x2E80 && r <= 0xA4CF, r >= 0xAC00 && r <= 0xD7A3, r >= 0xFF00 && r <= 0xFF60:
+			width += 2
+		default:
+			width++
+		}
+	}
+
+	return width
+}
+
+// padRight fills s with spaces out to width columns
+func padRight(s string, width int) string {
+	return s + strings.Repeat(" ", max(0, width-displayWidth(s)))
+}
+
+// padLeft right-aligns s in width columns
+func padLeft(s string, width int) string {
+	return strings.Repeat(" ", max(0, width-displayWidth(s))) + s
+}
+
+// truncate cuts s down to width columns, ending it with … when anything was cut
+func truncate(s string, width int) string {
+
+	if displayWidth(s) <= width {
+		return s
+	}
+
+	runes := []rune(s)
+	for len(runes) > 0 && displayWidth(string(runes))+1 > width {
+		runes = runes[:len(runes)-1]
+	}
+	return strings.TrimRight(string(runes), " ") + "…"
+}
+
+// terminalWidth is how wide stdout is, or 80 columns when it isn't a terminal.
+func terminalWidth() int {
+
+	fd := int(os.Stdout.Fd())
+	if term.IsTerminal(fd) {
+		if width, _, err := term.GetSize(fd); err == nil && width > 0 {
+			return width
+		}
+	}
+	return 80
+}
+
+type column struct {
+	// numbers line up on the right, words on the left
+	Right bool
+
+	// spaces before the column, 2 when it's left at 0. The first column has none.
+	Gap int
+
+	// this column is cut short when the table doesn't fit, after any Flex columns to the right of it
+	Flex bool
+}
+
+// the narrowest a Flex column is cut down to, so it still says something
+const minimumFlexWidth = 8
+
+// layoutTable lines rows up in columns, fitting them to the terminal after the two spaces lines are indented by.
+// Every row has one cell per column.
+func layoutTable(rows [][]string, columns []column) []string {
+
+	widths := make([]int, len(columns))
+	for _, row := range rows {
+		for i, cell := range row {
+			widths[i] = max(widths[i], displayWidth(cell))
+		}
+	}
+
+	gaps := make([]int, len(columns))
+	total := 0
+	for i, c := range columns {
+		if i > 0 {
+			gaps[i] = c.Gap
+			if gaps[i] == 0 {
+				gaps[i] = 2
+			}
+		}
+		total += gaps[i] + widths[i]
+	}
+
+	// the last Flex column is cut first, so what's least important goes on the end
+	over := total - (terminalWidth() - 2)
+	for i := len(columns) - 1; i >= 0 && over > 0; i-- {
+		if columns[i].Flex {
+			cut := min(over, max(0, widths[i]-minimumFlexWidth))
+			widths[i] -= cut
+			over -= cut
+		}
+	}
+
+	var lines []string
+	for _, row := range rows {
+
+		var line strings.Builder
+		for i, cell := range row {
+
+			if columns[i].Flex {
+				cell = truncate(cell, widths[i])
+			}
+
+			line.WriteString(strings.Repeat(" ", gaps[i]))
+			if columns[i].Right {
+				line.WriteString(padLeft(cell, widths[i]))
+			} else {
+				line.WriteString(padRight(cell, widths[i]))
+			}
+		}
+
+		lines = append(lines, strings.TrimRight(line.String(), " "))
+	}
+
+	return lines
+}
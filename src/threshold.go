@@ -0,0 +1,77 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// the most hours threshold looks through, when the provider forecasts further ahead than that
+const maxThresholdHours = 7 * 24
+
+// threshold finds the first hour from TIME on that the temperature goes below or above a value.
+func threshold(args []string) (string, error) {
+
+	if len(args) != 2 || (args[0] != "temp-below" && args[0] != "temp-above") {
+		return "", errUsage("threshold")
+	}
+
+	limit, err := parseTemperature(args[1])
+	if err != nil {
+		return "", err
+	}
+
+	_, ahead := provider.Window()
+	end := time.Now().Add(ahead)
+
+	if !internalTime.Before(end) {
+		return "", fmt.Errorf(tr("%s forecasts up to %s ahead"), provider.Name(), plural(maxForecastHours(), "hour"))
+	}
+
+	count := min(int(end.Sub(internalTime.Truncate(time.Hour))/time.Hour), maxThresholdHours)
+
+	hours, err := fetchHourly(internalTime, count)
+	if err != nil {
+		return "", err
+	}
+
+	// compared as they're shown, so an hour shown as 15° isn't below 15°
+	below := args[0] == "temp-below"
+	shownLimit := displayTemperature(limit)
+	crosses := func(c Conditions) bool {
+		shown := roundTemperature(displayTemperature(c.Temperature))
+		if below {
+			return shown < shownLimit
+		}
+		return shown > shownLimit
+	}
+
+	direction := tr("below")
+	if !below {
+		direction = tr("above")
+	}
+
+	found := firstCrossing(hours, crosses)
+	if found < 0 {
+		last := hours[len(hours)-1].Time.In(displayZone())
+		return fmt.Sprintf(tr("the temperature doesn't go %s %s before %s"), direction, formatTemperature(limit), formatTime(last)), nil
+	}
+
+	hour := hours[found]
+	at := hour.Time.In(displayZone())
+
+	if found == 0 {
+		return fmt.Sprintf(tr("the temperature is already %s %s at %s: %s"), direction, formatTemperature(limit), formatTime(at), formatTemperature(hour.Temperature)), nil
+	}
+
+	return fmt.Sprintf(tr("the temperature goes %s %s at %s %s: %s"), direction, formatTemperature(limit), formatTime(at), hoursFromNow(hour.Time), formatTemperature(hour.Temperature)), nil
+}
+
+// firstCrossing is the index of the first of hours that crosses, or -1 when none of them do
+func firstCrossing(hours []Conditions, crosses func(Conditions) bool) int {
+	for i, hour := range hours {
+		if crosses(hour) {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseTemperature(t *testing.T) {
+	useTestState(t)
+
+	tests := []struct {
+		units   string
+		text    string
+		celsius float64
+	}{
+		{"metric", "18", 18},
+		{"metric", "18C", 18},
+		{"metric", "18°C", 18},
+		{"metric", "18c", 18},
+		{"metric", "-2.5", -2.5},
+		{"metric", "65F", 18.333333333333332},
+		{"metric", " 32°F ", 0},
+
+		// without a unit, it's in the units temperatures are shown in
+		{"imperial", "212", 100},
+		{"imperial", "100C", 100},
+	}
+
+	for _, test := range tests {
+		config.Units = test.units
+		celsius, err := parseTemperature(test.text)
+		if err != nil || !approximately(celsius, test.celsius) {
+			t.Errorf("with units %s, parseTemperature(%q) = %v, %v, want %v", test.units, test.text, celsius, err, test.celsius)
+		}
+	}
+
+	for _, text := range []string{"", "warm", "18K", "C", "Inf", "NaN", "1e400"} {
+		if celsius, err := parseTemperature(text); err == nil {
+			t.Errorf("parseTemperature(%q) = %v, want an error", text, celsius)
+		}
+	}
+}
+
+func TestThreshold(t *testing.T) {
+	useTestState(t)
+
+	first := time.Now().Truncate(time.Hour).In(displayZone())
+	provider = stubProvider{hourly: func(start time.Time, count int) ([]Conditions, error) {
+		return hoursOf(start, 10, 12, 14.6, 16), nil
+	}}
+
+	tests := []struct {
+		args []string
+		want string
+	}{
+		// 14.6° is shown as 15°, which isn't above 15°
+		{[]string{"temp-above", "15"}, "the temperature goes above 15°C at " + formatTime(first.Add(3*time.Hour))},
+		{[]string{"temp-above", "9"}, "the temperature is already above 9°C"},
+		{[]string{"temp-below", "10"}, "the temperature doesn't go below 10°C before " + formatTime(first.Add(3*time.Hour))},
+		{[]string{"temp-above", "20"}, "the temperature doesn't go above 20°C"},
+	}
+
+	for _, test := range tests {
+		output, err := threshold(test.args)
+		if err != nil || !strings.HasPrefix(output, test.want) {
+			t.Errorf("threshold %s = %q, %v, want it to start %q", strings.Join(test.args, " "), output, err, test.want)
+		}
+	}
+
+	for _, args := range [][]string{nil, {"temp-above"}, {"temp-sideways", "15"}, {"temp-above", "warm"}} {
+		if _, err := threshold(args); err == nil {
+			t.Errorf("threshold %q succeeded, want an error", args)
+		}
+	}
+}
+
+// fetchHourly turns down a provider with no hours, so threshold always has a last hour to show
+func TestThresholdFetchHourlyError(t *testing.T) {
+	useTestState(t)
+	provider = stubProvider{hourly: func(time.Time, int) ([]Conditions, error) {
+		return nil, nil
+	}}
+
+	if output, err := threshold([]string{"temp-below", "0"}); err == nil || !strings.HasPrefix(err.Error(), "no weather data available for ") {
+		t.Errorf("threshold with no hours = %q, %v, want fetchHourly's error", output, err)
+	}
+}
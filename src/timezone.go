@@ -0,0 +1,53 @@
+package main
+
+import (
+	"errors"
+	"time"
+)
+
+// zoneOverride is the timezone set with tz, which times are shown in instead of LOCATION's. nil when there isn't one.
+var zoneOverride *time.Location
+
+// displayZone is the timezone TIME and forecasts are shown in: tz's, or else LOCATION's.
+func displayZone() *time.Location {
+	if zoneOverride != nil {
+		return zoneOverride
+	}
+	return locationZone()
+}
+
+func setTimezone(args []string) (string, error) {
+
+	if len(args) == 0 {
+		if zoneOverride != nil {
+			return "tz: " + zoneOverride.String(), nil
+		}
+		return "tz: " + displayZone().String() + ", " + tr("LOCATION's own"), nil
+	}
+
+	if len(args) > 1 {
+		return "", errUsage("tz")
+	}
+
+	if args[0] == "reset" {
+		updateState(func() {
+			zoneOverride = nil
+			internalTime = internalTime.In(displayZone())
+		})
+		return "  " + tr("times are shown in LOCATION's timezone, ") + displayZone().String(), nil
+	}
+
+	// the empty name and "Local" load fine, but aren't the name of anywhere
+	zone, err := time.LoadLocation(args[0])
+	if err != nil || args[0] == "" || args[0] == "Local" {
+		return "", errors.New("unknown timezone " + args[0] + ". Use a name like UTC or America/New_York")
+	}
+
+	// TIME stays the same moment, shown on the new clock
+	updateState(func() {
+		zoneOverride = zone
+		internalTime = internalTime.In(zone)
+	})
+
+	return "  " + tr("times are shown in ") + zone.String() + ": " + printTime(), nil
+}
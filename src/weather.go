@@ -0,0 +1,1508 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Doer is the part of *http.Client that weth uses. Every outbound request goes through httpClient, by way of
+// doWithRetry, so it can be swapped out without touching the commands.
+type Doer interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+var httpClient Doer = &http.Client{Timeout: 10 * time.Second}
+
+// currentClient is httpClient, read under stateMu since loading a snapshot replaces it while the background refresh
+// may be fetching
+func currentClient() Doer {
+	stateMu.RLock()
+	defer stateMu.RUnlock()
+	return httpClient
+}
+
+func getBody(url string) ([]byte, error) {
+
+	req, err := http.NewRequestWithContext(requestContext, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// some APIs, like the National Weather Service, refuse requests without one
+	req.Header.Set("User-Agent", "weth (https://github.com/r-pudasaini/weth)")
+
+	resp, err := doWithRetry(requestContext, req, maxRequestAttempts)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	// an error page or a rate limit notice would otherwise be decoded as weather with nothing in it
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, newHTTPStatusError(url, resp.StatusCode, body)
+	}
+
+	recordResponse(url, body)
+	return body, nil
+}
+
+func getJSON(url string, target any) error {
+
+	body, err := getBody(url)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(body, target)
+}
+
+// Conditions describe the weather at a single point in time. Values are always stored in metric units (Celsius, km/h)
+// and converted when they're displayed. Optional fields are nil when the provider didn't report them.
+type Conditions struct {
+	Time        time.Time `json:"time"`
+	Code        int       `json:"weather_code"`
+	Temperature float64   `json:"temperature_c"`
+	FeelsLike   *float64  `json:"feels_like_c,omitempty"`
+	Humidity    *float64  `json:"humidity_percent,omitempty"`
+	WindSpeed   *float64  `json:"wind_kmh,omitempty"`
+
+	// the strongest gusts, which can be well over the sustained WindSpeed
+	WindGusts *float64 `json:"wind_gusts_kmh,omitempty"`
+
+	// the direction the wind is coming from, in degrees clockwise from north
+	WindDirection *float64 `json:"wind_direction_degrees,omitempty"`
+
+	DewPoint *float64 `json:"dew_point_c,omitempty"`
+
+	// sea level pressure, in hPa
+	Pressure *float64 `json:"pressure_hpa,omitempty"`
+	UVIndex  *float64 `json:"uv_index,omitempty"`
+
+	// percent chance of any precipitation
+	PrecipitationChance *float64 `json:"precipitation_chance_percent,omitempty"`
+}
+
+// DailyConditions summarize a whole day in the location's timezone.
+type DailyConditions struct {
+	Date time.Time `json:"date"`
+	Code int       `json:"weather_code"`
+	High float64   `json:"high_c"`
+	Low  float64   `json:"low_c"`
+
+	PrecipitationChance *float64 `json:"precipitation_chance_percent,omitempty"`
+
+	// total precipitation, in mm
+	Precipitation *float64 `json:"precipitation_mm,omitempty"`
+
+	// the day's highest wind speed
+	WindSpeed *float64 `json:"wind_max_kmh,omitempty"`
+}
+
+// WMO weather interpretation codes, as used by Open-Meteo.
+var weatherCodes = map[int]string{
+	0: "Clear", 1: "Mostly Clear", 2: "Partly Cloudy", 3: "Overcast",
+	45: "Fog", 48: "Freezing Fog",
+	51: "Light Drizzle", 53: "Drizzle", 55: "Heavy Drizzle", 56: "Freezing Drizzle", 57: "Freezing Drizzle",
+	61: "Light Rain", 63: "Rain", 65: "Heavy Rain", 66: "Freezing Rain", 67: "Freezing Rain",
+	71: "Light Snow", 73: "Snow", 75: "Heavy Snow", 77: "Snow Grains",
+	80: "Light Showers", 81: "Showers", 82: "Heavy Showers", 85: "Snow Showers", 86: "Heavy Snow Showers",
+	95: "Thunderstorm", 96: "Thunderstorm", 99: "Thunderstorm",
+}
+
+func conditionName(code int) string {
+	if name, ok := weatherCodes[code]; ok {
+		return tr(name)
+	}
+	return "Code " + strconv.Itoa(code)
+}
+
+func celsiusToFahrenheit(celsius float64) float64 {
+	return celsius*9/5 + 32
+}
+
+func fahrenheitToCelsius(fahrenheit float64) float64 {
+	return (fahrenheit - 32) * 5 / 9
+}
+
+// comfortDelta is how far a temperature is from the comfort-temp setting, with --relative, e.g. "-3°C cooler"
+func comfortDelta(celsius float64) string {
+	return warmerOrCooler(config.ComfortTemperature, celsius, tr("just right"))
+}
+
+// relativeFlag takes --relative out of args
+func relativeFlag(args []string) ([]string, bool) {
+	rest := slices.DeleteFunc(slices.Clone(args), func(arg string) bool { return arg == "--relative" })
+	return rest, len(rest) < len(args)
+}
+
+// parseTemperature reads a temperature typed by the user, like 65F, 18C, 18°C or -2.5. Without a unit it's in the
+// units temperatures are being shown in, so it's Fahrenheit with units imperial. It returns degrees Celsius.
+func parseTemperature(text string) (float64, error) {
+
+	number := strings.TrimSuffix(strings.ToUpper(strings.TrimSpace(text)), "°")
+	unit := "C"
+	if activeUnits() == "imperial" {
+		unit = "F"
+	}
+
+	if strings.HasSuffix(number, "C") || strings.HasSuffix(number, "F") {
+		unit = number[len(number)-1:]
+		number = strings.TrimSuffix(number[:len(number)-1], "°")
+	}
+
+	value, err := strconv.ParseFloat(number, 64)
+	if err != nil || math.IsInf(value, 0) || math.IsNaN(value) {
+		return 0, fmt.Errorf("expected a temperature like 18C or 65F, got %s", text)
+	}
+
+	if unit == "F" {
+		return fahrenheitToCelsius(value), nil
+	}
+	return value, nil
+}
+
+// displayTemperature converts a temperature to the configured units
+func displayTemperature(celsius float64) float64 {
+	if activeUnits() == "imperial" {
+		return celsiusToFahrenheit(celsius)
+	}
+	return celsius
+}
+
+func kmhToMph(kmh float64) float64 {
+	return kmh / 1.609344
+}
+
+// roundTemperature rounds to config.TemperatureDecimals places. Halves round away from zero, so 2.5° and -2.5° are
+// 3° and -3°, and anything that rounds to zero is 0° rather than -0°.
+func roundTemperature(degrees float64) float64 {
+
+	scale := math.Pow(10, float64(config.TemperatureDecimals))
+	rounded := math.Round(degrees*scale) / scale
+
+	if rounded == 0 {
+		return 0
+	}
+	return rounded
+}
+
+// degrees renders a temperature already in unit, e.g. degrees(-3.2, "C") is -3°C
+func degrees(value float64, unit string) string {
+	return strconv.FormatFloat(roundTemperature(value), 'f', config.TemperatureDecimals, 64) + "°" + unit
+}
+
+// formatTemperature is how every command prints a temperature. With units both it's in Celsius with Fahrenheit after
+// it, as formatSpeed does for wind speeds, while everything else is shown in metric.
+func formatTemperature(celsius float64) string {
+	switch activeUnits() {
+	case "imperial":
+		return degrees(celsiusToFahrenheit(celsius), "F")
+	case "both":
+		return degrees(celsius, "C") + " (" + degrees(celsiusToFahrenheit(celsius), "F") + ")"
+	}
+	return degrees(celsius, "C")
+}
+
+func formatSpeed(kmh float64) string {
+	switch activeUnits() {
+	case "imperial":
+		return fmt.Sprintf("%.0f mph", kmhToMph(kmh))
+	case "both":
+		return fmt.Sprintf("%.0f km/h (%.0f mph)", kmh, kmhToMph(kmh))
+	}
+	return fmt.Sprintf("%.0f km/h", kmh)
+}
+
+func formatPressure(hPa float64) string {
+	if activeUnits() == "imperial" {
+		return fmt.Sprintf("%.2f inHg", hPa*0.02953)
+	}
+	return fmt.Sprintf("%.0f hPa", hPa)
+}
+
+func formatPrecipitation(mm float64) string {
+	if activeUnits() == "imperial" {
+		return fmt.Sprintf("%.2f in", mm/25.4)
+	}
+	return fmt.Sprintf("%.1f mm", mm)
+}
+
+// the coloring layer highlights chances at or above this
+const precipitationHighlight = 50
+
+func formatPrecipitationChance(percent float64) string {
+	return fmt.Sprintf("%.0f%% precip", percent)
+}
+
+// isWindy reports whether a wind speed is over config.WindAlert. The threshold is in the units speeds are shown in,
+// mph for imperial and km/h otherwise, and it's compared with the speed as it's shown, so 30 km/h is never windy
+// with a threshold of 30.
+func isWindy(kmh *float64) bool {
+
+	if config.WindAlert <= 0 || kmh == nil {
+		return false
+	}
+
+	speed := *kmh
+	if activeUnits() == "imperial" {
+		speed = kmhToMph(speed)
+	}
+
+	// rounded the way %.0f rounds it for formatSpeed
+	return math.RoundToEven(speed) > float64(config.WindAlert)
+}
+
+// windyTag is added to the hours and days isWindy picks out
+func windyTag() string {
+	return " (" + tr("windy") + ")"
+}
+
+// precipitationCell is one cell of a precipitation chance column, empty when the provider didn't report it.
+func precipitationCell(chance *float64) string {
+	if chance == nil {
+		return ""
+	}
+	return formatPrecipitationChance(*chance)
+}
+
+func formatDay(t time.Time) string {
+	return fmt.Sprintf("%s %s %d", weekdayAbbreviations[language][t.Weekday()], monthAbbreviations[language][t.Month()-1], t.Day())
+}
+
+// uvRisk follows the WHO exposure categories.
+func uvRisk(index float64) string {
+	return tr(uvCategory(index))
+}
+
+func uvCategory(index float64) string {
+	switch {
+	case index < 3:
+		return "Low"
+	case index < 6:
+		return "Moderate"
+	case index < 8:
+		return "High"
+	case index < 11:
+		return "Very High"
+	default:
+		return "Extreme"
+	}
+}
+
+var compassPoints = [...]string{"N", "NNE", "NE", "ENE", "E", "ESE", "SE", "SSE", "S", "SSW", "SW", "WSW", "W", "WNW", "NW", "NNW"}
+
+// arrows pointing N, NE, E, ... clockwise
+var compassArrows = [...]string{"↑", "↗", "→", "↘", "↓", "↙", "←", "↖"}
+
+func normalizeDegrees(degrees float64) float64 {
+	degrees = math.Mod(degrees, 360)
+	if degrees < 0 {
+		degrees += 360
+	}
+	return degrees
+}
+
+// compassPoint names the 16-point compass direction nearest to degrees. Each point covers 22.5°, centered on its
+// bearing, so N runs from 348.75° around to 11.25°.
+func compassPoint(degrees float64) string {
+	index := int(math.Floor((normalizeDegrees(degrees) + 11.25) / 22.5))
+	return compassPoints[index%len(compassPoints)]
+}
+
+// windArrow points where the wind is blowing toward, which is opposite to the direction it comes from.
+func windArrow(fromDegrees float64) string {
+	toward := normalizeDegrees(fromDegrees + 180)
+	index := int(math.Floor((toward + 22.5) / 45))
+	return compassArrows[index%len(compassArrows)]
+}
+
+// formatWind renders e.g. "NW ↘ 12 km/h (gusts 28)". It's empty when the provider didn't report the wind speed,
+// and leaves out the gusts when it didn't report those.
+func formatWind(c Conditions) string {
+
+	if c.WindSpeed == nil {
+		return ""
+	}
+
+	wind := formatSpeed(*c.WindSpeed)
+
+	if c.WindDirection != nil {
+		wind = compassPoint(*c.WindDirection) + " " + windArrow(*c.WindDirection) + " " + wind
+	}
+
+	if c.WindGusts != nil {
+		wind += " (" + tr("gusts") + " " + formatGusts(*c.WindGusts) + ")"
+	}
+
+	return wind
+}
+
+// formatGusts is a gust speed in the units the sustained wind beside it is already labelled with, so just the
+// number, except for both units where it needs both numbers labelled.
+func formatGusts(kmh float64) string {
+	switch activeUnits() {
+	case "imperial":
+		return fmt.Sprintf("%.0f", kmhToMph(kmh))
+	case "both":
+		return fmt.Sprintf("%.0f km/h, %.0f mph", kmh, kmhToMph(kmh))
+	}
+	return fmt.Sprintf("%.0f", kmh)
+}
+
+// apparentTemperature estimates how warm it feels outside. Wind chill applies at 10°C and below when there's a
+// breeze, and the heat index at 26.7°C (80°F) and above. In between, it feels like the air temperature.
+func apparentTemperature(celsius float64, humidity float64, windKmh float64) float64 {
+
+	if celsius <= 10 && windKmh > 4.8 {
+		// Environment Canada wind chill formula
+		v := math.Pow(windKmh, 0.16)
+		return 13.12 + 0.6215*celsius - 11.37*v + 0.3965*celsius*v
+	}
+
+	if celsius >= 26.7 {
+		// Rothfusz regression used by the US National Weather Service, which works in Fahrenheit
+		f := celsiusToFahrenheit(celsius)
+		h := humidity
+		index := -42.379 + 2.04901523*f + 10.14333127*h - 0.22475541*f*h - 6.83783e-3*f*f - 5.481717e-2*h*h +
+			1.22874e-3*f*f*h + 8.5282e-4*f*h*h - 1.99e-6*f*f*h*h
+		return fahrenheitToCelsius(index)
+	}
+
+	return celsius
+}
+
+// feelsLike prefers the provider's own value, and computes one from what was reported otherwise.
+func feelsLike(c Conditions) float64 {
+
+	if c.FeelsLike != nil {
+		return *c.FeelsLike
+	}
+
+	if c.Humidity == nil && c.Temperature >= 26.7 {
+		// the heat index is meaningless without humidity
+		return c.Temperature
+	}
+
+	var humidity, wind float64
+	if c.Humidity != nil {
+		humidity = *c.Humidity
+	}
+	if c.WindSpeed != nil {
+		wind = *c.WindSpeed
+	}
+
+	return apparentTemperature(c.Temperature, humidity, wind)
+}
+
+// differences under a degree aren't worth the noise of showing both values
+func showFeelsLike(c Conditions) bool {
+	return math.Abs(feelsLike(c)-c.Temperature) >= 1
+}
+
+// magnusDewPoint estimates the dew point from temperature and relative humidity, with the Magnus formula's
+// coefficients for -45°C to 60°C.
+func magnusDewPoint(celsius float64, humidity float64) float64 {
+	const b, c = 17.62, 243.12
+	gamma := math.Log(humidity/100) + b*celsius/(c+celsius)
+	return c * gamma / (b - gamma)
+}
+
+// dewPoint prefers the provider's own value, and computes one from temperature and humidity otherwise. It's nil when
+// there's nothing to compute it from.
+func dewPoint(c Conditions) *float64 {
+
+	if c.DewPoint != nil {
+		return c.DewPoint
+	}
+
+	if c.Humidity == nil || *c.Humidity <= 0 {
+		return nil
+	}
+
+	computed := magnusDewPoint(c.Temperature, *c.Humidity)
+	return &computed
+}
+
+// comfortLevel is how muggy a dew point feels
+func comfortLevel(dewPointCelsius float64) string {
+	switch {
+	case dewPointCelsius < 10:
+		return "Dry"
+	case dewPointCelsius < 16:
+		return "Comfortable"
+	case dewPointCelsius < 21:
+		return "Humid"
+	default:
+		return "Oppressive"
+	}
+}
+
+// alignFields lays out label/value pairs with the values lined up in a column.
+func alignFields(rows [][2]string) string {
+
+	width := 0
+	for _, row := range rows {
+		width = max(width, len(row[0]))
+	}
+
+	var lines []string
+	for _, row := range rows {
+		lines = append(lines, fmt.Sprintf("%-*s  %s", width+1, row[0]+":", row[1]))
+	}
+
+	return strings.Join(lines, "\n  ")
+}
+
+func formatHour(t time.Time) string {
+
+	if !militaryTime {
+		hour := t.Hour() % 12
+		if hour == 0 {
+			hour = 12
+		}
+		if t.Hour() >= 12 {
+			return strconv.Itoa(hour) + "PM"
+		}
+		return strconv.Itoa(hour) + "AM"
+	}
+
+	return strconv.Itoa(t.Hour()) + ":00"
+}
+
+// formatClock is formatHour with the minutes, e.g. 9:37AM or 9:37
+func formatClock(t time.Time) string {
+
+	if !militaryTime {
+		hour := t.Hour() % 12
+		if hour == 0 {
+			hour = 12
+		}
+		suffix := "AM"
+		if t.Hour() >= 12 {
+			suffix = "PM"
+		}
+		return fmt.Sprintf("%d:%02d%s", hour, t.Minute(), suffix)
+	}
+
+	return fmt.Sprintf("%d:%02d", t.Hour(), t.Minute())
+}
+
+// snapToHour picks which of hours stands in for t. With config.HourSnapping "floor" it's the last one at or before t,
+// and otherwise it's the nearest one, with half past going to the later hour. hours must be in order.
+func snapToHour(t time.Time, hours []Conditions) int {
+
+	best := 0
+
+	for i, hour := range hours {
+
+		if config.HourSnapping == "floor" {
+			if !hour.Time.After(t) {
+				best = i
+			}
+			continue
+		}
+
+		if hour.Time.Sub(t).Abs() <= hours[best].Time.Sub(t).Abs() {
+			best = i
+		}
+	}
+
+	return best
+}
+
+// snappedStart is where an hourly listing asked for at t begins
+func snappedStart(t time.Time) time.Time {
+	if config.HourSnapping == "floor" {
+		return t.Truncate(time.Hour)
+	}
+	return t.Round(time.Hour)
+}
+
+// snapNote says which hour is being shown when TIME isn't on the hour, or is empty when it is.
+func snapNote(shown time.Time, wanted time.Time) string {
+
+	if shown.Truncate(time.Minute).Equal(wanted.Truncate(time.Minute)) {
+		return ""
+	}
+
+	if config.HourSnapping == "floor" {
+		return fmt.Sprintf(tr("showing %s (the hour %s is in)"), formatHour(shown.In(displayZone())), formatClock(wanted))
+	}
+	return fmt.Sprintf(tr("showing %s (nearest to %s)"), formatHour(shown.In(displayZone())), formatClock(wanted))
+}
+
+// pressure changing by less than steadyPressureChange hPa over pressureTrendWindow counts as steady
+const steadyPressureChange = 1.0
+const pressureTrendWindow = 3 * time.Hour
+
+func pressureTrend(change float64) string {
+	switch {
+	case change >= steadyPressureChange:
+		return "↑"
+	case change <= -steadyPressureChange:
+		return "↓"
+	default:
+		return "→"
+	}
+}
+
+func formatPressureChange(hPa float64) string {
+	if activeUnits() == "imperial" {
+		return fmt.Sprintf("%+.2f inHg", hPa*0.02953)
+	}
+	return fmt.Sprintf("%+.1f hPa", hPa)
+}
+
+// pressureBefore is the pressure pressureTrendWindow before t, or nil when the provider doesn't have it.
+func pressureBefore(t time.Time) *float64 {
+
+	hours, err := providerHours(t.Add(-pressureTrendWindow), 1)
+	if err != nil || len(hours) == 0 {
+		return nil
+	}
+
+	return hours[0].Pressure
+}
+
+// describeNow lists what's known about c, with which way the pressure is heading when earlierPressure is known, and
+// how far the temperature is from comfort-temp when relative is set.
+func describeNow(c Conditions, earlierPressure *float64, relative bool) string {
+
+	temperature := formatTemperature(c.Temperature)
+	if relative {
+		temperature += " (" + comfortDelta(c.Temperature) + ")"
+	}
+
+	rows := [][2]string{
+		{tr("Conditions"), describeConditions(c)},
+		{tr("Temperature"), temperature},
+	}
+
+	if showFeelsLike(c) {
+		rows = append(rows, [2]string{tr("Feels like"), formatTemperature(feelsLike(c))})
+	}
+
+	if wind := formatWind(c); wind != "" {
+		rows = append(rows, [2]string{tr("Wind"), wind})
+	}
+
+	if c.Humidity != nil {
+		rows = append(rows, [2]string{tr("Humidity"), fmt.Sprintf("%.0f%%", *c.Humidity)})
+	}
+
+	if dew := dewPoint(c); dew != nil {
+		rows = append(rows, [2]string{tr("Dew point"), formatTemperature(*dew) + " (" + tr(comfortLevel(*dew)) + ")"})
+	}
+
+	if c.Pressure != nil {
+		pressure := formatPressure(*c.Pressure)
+		if earlierPressure != nil {
+			change := *c.Pressure - *earlierPressure
+			pressure += fmt.Sprintf(" %s %s %s %.0fh", pressureTrend(change), formatPressureChange(change), tr("in"), pressureTrendWindow.Hours())
+		}
+		rows = append(rows, [2]string{tr("Pressure"), pressure})
+	}
+
+	if c.UVIndex != nil {
+		rows = append(rows, [2]string{tr("UV index"), fmt.Sprintf("%.0f (%s)", *c.UVIndex, uvRisk(*c.UVIndex))})
+	}
+
+	return alignFields(rows)
+}
+
+func nowWeather(args []string) (string, error) {
+
+	args, field, toField, err := fieldFlag(args)
+	if err != nil {
+		return "", err
+	}
+
+	args, relative := relativeFlag(args)
+
+	if !internalLocation.hasCoordinates() {
+		return "", errors.New(tr("no coordinates known for this location. Try setting it again with setloc"))
+	}
+
+	c, forecastHour, err := conditionsNow()
+	if err != nil {
+		return "", err
+	}
+
+	if toField {
+		return printField(c, field)
+	}
+
+	if jsonOutput {
+		return writeJSON(c)
+	}
+
+	historical := forecastHour && isHistorical(c.Time)
+
+	// unindented, so a status bar gets the line as it is
+	if slices.Contains(args, "--oneline") {
+		line := onelineNow(c, relative)
+		if historical {
+			line += " " + historicalMark()
+		}
+		return unindented + line, nil
+	}
+
+	var lines []string
+
+	if historical {
+		lines = append(lines, formatTime(c.Time.In(displayZone()))+" "+historicalMark())
+	}
+
+	if note := snapNote(c.Time, internalTime); forecastHour && note != "" {
+		lines = append(lines, note)
+	}
+
+	return strings.Join(append(lines, describeNow(c, pressureBefore(c.Time), relative)), "\n  "), nil
+}
+
+// onelineNow fits c on one line for status bars, e.g. "San Francisco: 18°C ☀️ Clear, wind 12 km/h", with the ASCII
+// icon when colors are off. relative adds how far the temperature is from comfort-temp after it.
+func onelineNow(c Conditions, relative bool) string {
+
+	temperature := formatTemperature(c.Temperature)
+	if relative {
+		temperature += " (" + comfortDelta(c.Temperature) + ")"
+	}
+
+	line := fmt.Sprintf("%s: %s %s %s", internalLocation.City, temperature, conditionIcon(c.Code), conditionName(c.Code))
+
+	if c.WindSpeed != nil {
+		line += ", " + tr("wind") + " " + formatSpeed(*c.WindSpeed)
+	}
+	return line
+}
+
+// conditionsNow is the weather at TIME in LOCATION. forecastHour is true when it's the forecast for the hour
+// snapToHour picked, rather than the provider's current conditions.
+func conditionsNow() (c Conditions, forecastHour bool, err error) {
+	return conditionsAt(internalTime)
+}
+
+// conditionsAt is the weather at t in LOCATION, the way conditionsNow finds it for TIME.
+func conditionsAt(t time.Time) (c Conditions, forecastHour bool, err error) {
+
+	// the provider's current conditions are more precise than its forecast for this hour
+	if time.Since(t).Abs() < time.Hour {
+		current, err := provider.Current(internalLocation.Lat, internalLocation.Lon)
+		if err == nil {
+			return current, false, nil
+		}
+	}
+
+	// the hour t is in and the one after, to snap to
+	hours, err := fetchHourly(t, 2)
+	if err != nil {
+		return Conditions{}, true, err
+	}
+
+	return hours[snapToHour(t, hours)], true, nil
+}
+
+func hoursForecast(args []string) (string, error) {
+
+	args, csvPath, toCSV := csvFlag(args)
+	args, relative := relativeFlag(args)
+
+	if len(args) == 0 {
+		args = []string{strconv.Itoa(config.HoursDefault)}
+	}
+
+	start, count := snappedStart(internalTime), 0
+
+	// a range of hours or a later day doesn't start at TIME
+	explicitStart := args[0] == "--day" || len(args) >= 2
+
+	if args[0] == "--day" {
+
+		var err error
+		start, count, err = dayHours(args[1:])
+		if err != nil {
+			return "", err
+		}
+
+	} else if len(args) >= 2 {
+
+		var err error
+		start, count, err = hourRange(args[0], args[1])
+		if err != nil {
+			return "", err
+		}
+
+	} else {
+
+		var err error
+		count, err = strconv.Atoi(args[0])
+		if err != nil || count < 0 {
+			return "", errors.New("Expected a positive number of hours, got " + args[0])
+		}
+
+		if count <= 1 && !toCSV {
+			if relative {
+				return nowWeather([]string{"--relative"})
+			}
+			return nowWeather(nil)
+		}
+
+		if count > maxForecastHours() {
+			return "", forecastLimit(maxForecastHours(), "hour")
+		}
+
+		count = max(count, 1)
+	}
+
+	hours, err := fetchHourly(start, count)
+	if err != nil {
+		return "", err
+	}
+
+	if toCSV {
+		return writeCSV(csvPath, hourlyRecords(hours))
+	}
+
+	if jsonOutput {
+		return writeJSON(forecastJSON{Location: internalLocation.coarsened(), Time: internalTime, Hours: hours})
+	}
+
+	header := forecastHeader(printTime())
+	if explicitStart {
+		header = forecastHeader(formatTime(start))
+	}
+
+	if isHistorical(start) {
+		header += " " + historicalMark()
+	}
+
+	if explicitStart {
+		return header + "\n  " + hourlyListing(hours, start, relative), nil
+	}
+
+	lines := []string{header}
+	if note := snapNote(start, internalTime); note != "" {
+		lines = append(lines, note)
+	}
+	return strings.Join(append(lines, hourlyListing(hours, internalTime, relative)), "\n  "), nil
+}
+
+// dayStart is hour:minute on the day offset days after internalTime's date, on LOCATION's clock.
+func dayStart(offset int, hour int, minute int) time.Time {
+	year, month, day := internalTime.Date()
+	return time.Date(year, month, day+offset, hour, minute, 0, 0, internalTime.Location())
+}
+
+// dayHours reads the arguments after hours --day: an offset in days from TIME's date, then optionally how many hours
+// and the hour to start at. Without them it's the whole day from midnight.
+func dayHours(args []string) (time.Time, int, error) {
+
+	if len(args) == 0 || len(args) > 3 {
+		return time.Time{}, 0, errUsage("hours")
+	}
+
+	offset, err := strconv.Atoi(args[0])
+	if err != nil {
+		return time.Time{}, 0, errors.New("Expected a number of days after TIME's date, got " + args[0])
+	}
+
+	count := 24
+	if len(args) > 1 {
+		count, err = strconv.Atoi(args[1])
+		if err != nil || count < 1 {
+			return time.Time{}, 0, errors.New("Expected a positive number of hours, got " + args[1])
+		}
+	}
+
+	hour, minute := 0, 0
+	if len(args) > 2 {
+		hour, minute, err = parseClock(args[2])
+		if err != nil {
+			return time.Time{}, 0, err
+		}
+	}
+
+	start := dayStart(offset, hour, minute)
+
+	if _, ahead := provider.Window(); start.After(time.Now().Add(ahead)) {
+		return time.Time{}, 0, errors.New(formatDay(start) + " is past the end of the forecast")
+	}
+
+	return start, count, nil
+}
+
+// hourRange finds the hours from startText to endText, like "9:00" and "17:00", on internalTime's date.
+func hourRange(startText string, endText string) (time.Time, int, error) {
+
+	startHour, startMinute, err := parseClock(startText)
+	if err != nil {
+		return time.Time{}, 0, err
+	}
+
+	endHour, endMinute, err := parseClock(endText)
+	if err != nil {
+		return time.Time{}, 0, err
+	}
+
+	year, month, day := internalTime.Date()
+	start := time.Date(year, month, day, startHour, startMinute, 0, 0, internalTime.Location())
+	end := time.Date(year, month, day, endHour, endMinute, 0, 0, internalTime.Location())
+
+	if !end.After(start) {
+		return time.Time{}, 0, errors.New("the end of the range has to be after its start")
+	}
+
+	if _, ahead := provider.Window(); end.After(time.Now().Add(ahead)) {
+		return time.Time{}, 0, errors.New(endText + " on " + formatDay(end) + " is past the end of the forecast")
+	}
+
+	count := int(end.Truncate(time.Hour).Sub(start.Truncate(time.Hour))/time.Hour) + 1
+	return start, count, nil
+}
+
+// maxForecastHours and maxForecastDays are how far ahead the provider forecasts, from its Window
+func maxForecastHours() int {
+	_, ahead := provider.Window()
+	return int(ahead.Hours())
+}
+
+func maxForecastDays() int {
+	return maxForecastHours() / 24
+}
+
+// forecastLimit is the error for asking for more of a forecast than the provider has
+func forecastLimit(limit int, unit string) error {
+	return fmt.Errorf(tr("%s forecasts at most %s"), provider.Name(), plural(limit, unit))
+}
+
+// warnOutsideWindow warns when some of start to end is further from the real current time than the provider has
+// weather for, since what comes back is then short or empty.
+func warnOutsideWindow(start time.Time, end time.Time) {
+
+	history, ahead := provider.Window()
+	now := time.Now()
+
+	switch {
+	case start.Before(now.Add(-history)):
+		printWarning(fmt.Sprintf(tr("%s only has weather from the last %s, so some of this may be missing. resettime goes back to now"), provider.Name(), plural(int(history.Hours()/24), "day")))
+	case end.After(now.Add(ahead)):
+		printWarning(fmt.Sprintf(tr("%s only forecasts %s ahead, so some of this may be missing. resettime goes back to now"), provider.Name(), plural(int(ahead.Hours()/24), "day")))
+	}
+}
+
+func fetchHourly(start time.Time, count int) ([]Conditions, error) {
+
+	if !internalLocation.hasCoordinates() {
+		return nil, errors.New(tr("no coordinates known for this location. Try setting it again with setloc"))
+	}
+
+	hours, err := providerHours(start, count)
+	if errors.Is(err, errNoHistory) {
+		return nil, fmt.Errorf(tr("%s has no past weather, only forecasts. resettime goes back to now"), provider.Name())
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s%w", tr("could not fetch weather: "), err)
+	}
+
+	if len(hours) == 0 {
+		return nil, errors.New(tr("no weather data available for ") + printTime())
+	}
+
+	return hours, nil
+}
+
+// isHistorical reports whether t is in an hour that's already over, so its weather comes from the provider's history
+func isHistorical(t time.Time) bool {
+	return t.Truncate(time.Hour).Before(time.Now().Truncate(time.Hour))
+}
+
+// historicalMark is added to weather that has already happened
+func historicalMark() string {
+	return "(" + tr("historical") + ")"
+}
+
+// providerHours gets count hours from start, with the hours that are over from the provider's history and the rest
+// from its forecast.
+func providerHours(start time.Time, count int) ([]Conditions, error) {
+
+	start = start.Truncate(time.Hour)
+	past := 0
+	for past < count && isHistorical(start.Add(time.Duration(past)*time.Hour)) {
+		past++
+	}
+
+	var hours []Conditions
+
+	if past > 0 {
+		history, err := provider.History(internalLocation.Lat, internalLocation.Lon, start, past)
+		if err != nil {
+			return nil, err
+		}
+		hours = history
+	}
+
+	if past < count {
+		ahead := start.Add(time.Duration(past) * time.Hour)
+		warnOutsideWindow(ahead, start.Add(time.Duration(count-1)*time.Hour))
+
+		forecast, err := provider.Hourly(internalLocation.Lat, internalLocation.Lon, ahead, count-past)
+		if err != nil {
+			return nil, err
+		}
+		hours = append(hours, forecast...)
+	}
+
+	return hours, nil
+}
+
+// clockChange describes a daylight saving transition between two times, or is empty when their offsets match.
+func clockChange(before time.Time, after time.Time) string {
+
+	_, beforeOffset := before.Zone()
+	_, afterOffset := after.Zone()
+	shift := time.Duration(afterOffset-beforeOffset) * time.Second
+
+	switch {
+	case shift > 0:
+		return fmt.Sprintf("%s %s", tr("clocks forward"), strings.TrimSuffix(shift.String(), "0m0s"))
+	case shift < 0:
+		return fmt.Sprintf("%s %s", tr("clocks back"), strings.TrimSuffix((-shift).String(), "0m0s"))
+	}
+
+	return ""
+}
+
+// forecastHeader heads hours and days output with what it's for, so it still makes sense scrolled back or copied
+func forecastHeader(from string) string {
+	return fmt.Sprintf(tr("%s, from %s"), strings.Join(nonEmpty(internalLocation.City, internalLocation.Region), ", "), from)
+}
+
+// temperatureExtremes finds the warmest and coldest of hours as they're displayed, so two hours that both show 17°
+// count as a tie, which goes to the first of them. Both are -1 when there's nothing to pick out: fewer than two hours,
+// or every hour the same.
+func temperatureExtremes(hours []Conditions) (warmest int, coldest int) {
+
+	if len(hours) < 2 {
+		return -1, -1
+	}
+
+	shown := func(i int) float64 {
+		return roundTemperature(displayTemperature(hours[i].Temperature))
+	}
+
+	warmest, coldest = 0, 0
+	for i := range hours {
+		if shown(i) > shown(warmest) {
+			warmest = i
+		}
+		if shown(i) < shown(coldest) {
+			coldest = i
+		}
+	}
+
+	if shown(warmest) == shown(coldest) {
+		return -1, -1
+	}
+	return warmest, coldest
+}
+
+// hourlyListing is a line for each hour. headed is the time the header above it shows, which the first of the dates
+// marked where the listing goes past midnight counts from. relative adds a column for how far each hour is from
+// comfort-temp.
+func hourlyListing(hours []Conditions, headed time.Time, relative bool) string {
+
+	showChance := false
+	for _, hour := range hours {
+		showChance = showChance || hour.PrecipitationChance != nil
+	}
+
+	zone := displayZone()
+	warmest, coldest := temperatureExtremes(hours)
+
+	columns := []column{{}, {Gap: 1}, {Right: true}}
+	if relative {
+		columns = append(columns, column{Right: true})
+	}
+	columns = append(columns, column{})
+	if showChance {
+		columns = append(columns, column{})
+	}
+	columns = append(columns, column{Flex: true})
+
+	var rows [][]string
+	var rollovers []int
+
+	for i, hour := range hours {
+
+		local := hour.Time.In(zone)
+
+		// hours only show the clock, so where the listing goes past midnight on LOCATION's clock the new date is
+		// marked, or 11PM, 12AM, 1AM wouldn't say which day the 1AM is
+		previous := headed
+		if i > 0 {
+			previous = hours[i-1].Time
+		}
+		if previous.In(zone).Format(time.DateOnly) != local.Format(time.DateOnly) {
+			rollovers = append(rollovers, i)
+		}
+
+		row := []string{formatHour(local), hoursFromNow(hour.Time), formatTemperature(hour.Temperature)}
+		if relative {
+			row = append(row, comfortDelta(hour.Temperature))
+		}
+		row = append(row, formatWind(hour))
+		if showChance {
+			row = append(row, precipitationCell(hour.PrecipitationChance))
+		}
+
+		// the extras come after the condition, so they're what's cut when the terminal is narrow
+		condition := conditionIcon(hour.Code) + " " + conditionName(hour.Code)
+
+		switch i {
+		case warmest:
+			condition += " (" + tr("high") + ")"
+		case coldest:
+			condition += " (" + tr("low") + ")"
+		}
+
+		if showFeelsLike(hour) {
+			condition += " (" + tr("feels like") + " " + formatTemperature(feelsLike(hour)) + ")"
+		}
+
+		if isWindy(hour.WindSpeed) {
+			condition += windyTag()
+		}
+
+		if i > 0 {
+			if change := clockChange(hours[i-1].Time.In(zone), local); change != "" {
+				condition += " [" + change + "]"
+			}
+		}
+
+		rows = append(rows, append(row, condition))
+	}
+
+	lines := layoutTable(rows, columns)
+
+	// from the last, so inserting one doesn't move where the earlier ones go
+	for _, i := range slices.Backward(rollovers) {
+		lines = slices.Insert(lines, i, "— "+formatDay(hours[i].Time.In(zone))+" —")
+	}
+
+	return strings.Join(lines, "\n  ")
+}
+
+func daysForecast(args []string) (string, error) {
+
+	args, csvPath, toCSV := csvFlag(args)
+
+	withNormals := slices.Contains(args, "--with-normals")
+	args = slices.DeleteFunc(args, func(arg string) bool { return arg == "--with-normals" })
+
+	if len(args) == 0 {
+		args = []string{strconv.Itoa(config.DaysDefault)}
+	}
+
+	start, count := internalTime, 1
+
+	if args[0] == "on" {
+
+		if len(args) < 3 {
+			return "", errUsage("days")
+		}
+
+		var err error
+		start, err = dayOn(args[1], args[2])
+		if err != nil {
+			return "", err
+		}
+
+	} else {
+
+		var err error
+		count, err = strconv.Atoi(args[0])
+		if err != nil || count < 1 {
+			return "", errors.New("Expected a positive number of days, got " + args[0])
+		}
+
+		if count > maxForecastDays() {
+			return "", forecastLimit(maxForecastDays(), "day")
+		}
+	}
+
+	days, err := fetchDaily(start, count)
+	if err != nil {
+		return "", err
+	}
+
+	if toCSV {
+		return writeCSV(csvPath, dailyRecords(days))
+	}
+
+	// without normals, the forecast is still worth showing
+	var normals []DailyNormal
+	if withNormals {
+		normals, err = fetchNormals(start, count)
+		if err != nil {
+			printWarning(tr("showing the forecast without normals: ") + err.Error())
+		}
+	}
+
+	if jsonOutput {
+		return writeJSON(forecastJSON{Location: internalLocation.coarsened(), Time: start, Days: days, Normals: normals})
+	}
+
+	date := fmt.Sprintf("%s %d, %d", monthName(start.Month()), start.Day(), start.Year())
+	return forecastHeader(date) + "\n  " + dailyListing(days, normals), nil
+}
+
+// dayOn is a single date in internalTime's year, like "june" "10", that the forecast covers.
+func dayOn(monthText string, dayText string) (time.Time, error) {
+
+	month, err := parseMonth(monthText)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	day, err := strconv.Atoi(dayText)
+	if err != nil {
+		return time.Time{}, errors.New("Expected a number for Day, got " + dayText)
+	}
+
+	year := internalTime.Year()
+	if day < 1 || day > daysIn(time.Month(month), year) {
+		return time.Time{}, errors.New("Expected Day in range 1-" + strconv.Itoa(daysIn(time.Month(month), year)) + ", got " + dayText)
+	}
+
+	date := time.Date(year, time.Month(month), day, 0, 0, 0, 0, internalTime.Location())
+	now := time.Now().In(date.Location())
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, date.Location())
+
+	if date.Before(today) {
+		return time.Time{}, errors.New(formatDay(date) + " is in the past")
+	}
+
+	if _, ahead := provider.Window(); date.After(now.Add(ahead)) {
+		return time.Time{}, errors.New(formatDay(date) + " is past the end of the forecast")
+	}
+
+	return date, nil
+}
+
+func fetchDaily(start time.Time, count int) ([]DailyConditions, error) {
+
+	if !internalLocation.hasCoordinates() {
+		return nil, errors.New(tr("no coordinates known for this location. Try setting it again with setloc"))
+	}
+
+	days, err := providerDays(start, count)
+	if err != nil {
+		return nil, fmt.Errorf("%s%w", tr("could not fetch weather: "), err)
+	}
+
+	if len(days) == 0 {
+		return nil, errors.New(tr("no weather data available for ") + printTime())
+	}
+
+	return days, nil
+}
+
+// providerDays gets count days from the day containing start, adding up the hours of each day when the provider
+// doesn't have daily forecasts of its own.
+func providerDays(start time.Time, count int) ([]DailyConditions, error) {
+
+	if provider.HasDaily() {
+		warnOutsideWindow(start, start.AddDate(0, 0, count-1))
+		return provider.Daily(internalLocation.Lat, internalLocation.Lon, start, count)
+	}
+
+	zone := locationZone()
+	year, month, day := start.In(zone).Date()
+	first := time.Date(year, month, day, 0, 0, 0, 0, zone)
+
+	// a day the clocks change on isn't 24 hours long
+	end := first.AddDate(0, 0, count)
+
+	hours, err := providerHours(first, int(end.Sub(first)/time.Hour))
+	if err != nil {
+		return nil, err
+	}
+
+	return aggregateDays(hours, zone), nil
+}
+
+// aggregateDays sums hours up into days, split at midnight in zone. A day's weather is what most of its hours had,
+// with ties going to the higher code, which is the more notable weather. Days are dated like a provider's, at
+// midnight UTC on the local date.
+func aggregateDays(hours []Conditions, zone *time.Location) []DailyConditions {
+
+	var days []DailyConditions
+	var codes []map[int]int
+
+	for _, hour := range hours {
+
+		year, month, date := hour.Time.In(zone).Date()
+		midnight := time.Date(year, month, date, 0, 0, 0, 0, time.UTC)
+
+		if len(days) == 0 || !days[len(days)-1].Date.Equal(midnight) {
+			days = append(days, DailyConditions{Date: midnight, High: hour.Temperature, Low: hour.Temperature})
+			codes = append(codes, map[int]int{})
+		}
+
+		day := &days[len(days)-1]
+		day.High = math.Max(day.High, hour.Temperature)
+		day.Low = math.Min(day.Low, hour.Temperature)
+		day.PrecipitationChance = maxReported(day.PrecipitationChance, hour.PrecipitationChance)
+		day.WindSpeed = maxReported(day.WindSpeed, hour.WindSpeed)
+
+		codes[len(codes)-1][hour.Code]++
+	}
+
+	for i, counts := range codes {
+		best := -1
+		for code, count := range counts {
+			if best < 0 || count > counts[best] || (count == counts[best] && code > best) {
+				best = code
+			}
+		}
+		days[i].Code = best
+	}
+
+	return days
+}
+
+// maxReported is the larger of two values either of which may be unreported
+func maxReported(a *float64, b *float64) *float64 {
+
+	if a == nil {
+		return b
+	}
+
+	if b == nil || *a >= *b {
+		return a
+	}
+	return b
+}
+
+// highTrends compares each day's high with the day before's, as they're displayed, so an arrow never contradicts
+// the numbers next to it. The first day has nothing to compare with and gets a dash.
+func highTrends(days []DailyConditions) []string {
+
+	var trends []string
+
+	for i, day := range days {
+
+		if i == 0 {
+			trends = append(trends, "-")
+			continue
+		}
+
+		today, yesterday := roundTemperature(displayTemperature(day.High)), roundTemperature(displayTemperature(days[i-1].High))
+
+		switch {
+		case today > yesterday:
+			trends = append(trends, "↑")
+		case today < yesterday:
+			trends = append(trends, "↓")
+		default:
+			trends = append(trends, "→")
+		}
+	}
+
+	return trends
+}
+
+func dailyListing(days []DailyConditions, normals []DailyNormal) string {
+
+	showChance, showAmount := false, false
+	for _, day := range days {
+		showChance = showChance || day.PrecipitationChance != nil
+		showAmount = showAmount || day.Precipitation != nil
+	}
+
+	// the high's trend arrow and the low sit close to it: 23°C ↑ / 7°C
+	columns := []column{{}, {Gap: 1}, {Right: true}, {Gap: 1}, {Gap: 1}, {Gap: 1}}
+
+	// the usual high and low, and how the day compares: usual 20°C / 5°C  +3°C warmer
+	usual := normalsByDate(normals)
+	showNormals := len(usual) > 0
+	if showNormals {
+		columns = append(columns, column{}, column{})
+	}
+
+	if showChance {
+		columns = append(columns, column{})
+	}
+	if showAmount {
+		columns = append(columns, column{})
+	}
+	columns = append(columns, column{Flex: true}, column{Flex: true})
+
+	trends := highTrends(days)
+	zone := locationZone()
+
+	var rows [][]string
+	for i, day := range days {
+
+		row := []string{formatDay(day.Date), daysFromNow(day.Date), formatTemperature(day.High), trends[i], "/", formatTemperature(day.Low)}
+
+		if showNormals {
+			if normal, ok := usual[day.Date.Format(time.DateOnly)]; ok {
+				row = append(row, tr("usual")+" "+formatTemperature(normal.High)+" / "+formatTemperature(normal.Low), temperatureAnomaly(day, normal))
+			} else {
+				row = append(row, "", "")
+			}
+		}
+
+		if showChance {
+			row = append(row, precipitationCell(day.PrecipitationChance))
+		}
+
+		if showAmount {
+			amount := ""
+			if day.Precipitation != nil {
+				amount = formatPrecipitation(*day.Precipitation)
+			}
+			row = append(row, amount)
+		}
+
+		moon := formatMoon(day.Date)
+
+		midnight := time.Date(day.Date.Year(), day.Date.Month(), day.Date.Day(), 0, 0, 0, 0, zone)
+		if change := clockChange(midnight, midnight.AddDate(0, 0, 1)); change != "" {
+			moon += " [" + change + "]"
+		}
+
+		condition := conditionIcon(day.Code) + " " + conditionName(day.Code)
+		if isWindy(day.WindSpeed) {
+			condition += windyTag()
+		}
+
+		rows = append(rows, append(row, condition, moon))
+	}
+
+	return strings.Join(layoutTable(rows, columns), "\n  ")
+}
+
+func isUnits(name string) bool {
+	return name == "metric" || name == "imperial" || name == "both"
+}
+
+// locationKey is the name a location's units are saved under. Case, spacing, and whether the country was given as
+// a code don't make a different place.
+func locationKey(loc Location) string {
+
+	loc = normalizeCountry(loc)
+
+	var parts []string
+	for _, part := range nonEmpty(loc.City, loc.Region, loc.Country) {
+		parts = append(parts, strings.ToLower(strings.Join(strings.Fields(part), " ")))
+	}
+
+	return strings.Join(parts, ", ")
+}
+
+// activeUnits is what weather is shown in: a loaded snapshot's units, the units saved for LOCATION, or globalUnits
+// when it has none.
+func activeUnits() string {
+	if snapshotUnits != "" {
+		return snapshotUnits
+	}
+	if units, ok := config.LocationUnits[locationKey(internalLocation)]; ok {
+		return units
+	}
+	return globalUnits()
+}
+
+// countryDefaultUnits are the units used where config doesn't have any, which are the ones the country weth found
+// itself in from its IP address uses. They stay metric when the location came from anywhere else.
+var countryDefaultUnits = "metric"
+
+// unitsCountry is the country countryDefaultUnits were picked for, or empty when they weren't
+var unitsCountry string
+
+// snapshotUnits are the units a loaded snapshot was made with. They're only for this session, so they're never saved
+// to the config, and choosing units with units goes back to the config's.
+var snapshotUnits string
+
+// globalUnits are the units set with units, or countryDefaultUnits until they've been set
+func globalUnits() string {
+	if config.Units != "" {
+		return config.Units
+	}
+	return countryDefaultUnits
+}
+
+func setUnits(args []string) (string, error) {
+
+	key := locationKey(internalLocation)
+
+	if len(args) == 0 {
+		if snapshotUnits != "" {
+			return "units: " + snapshotUnits + ", " + tr("from the loaded snapshot"), nil
+		}
+		if units, ok := config.LocationUnits[key]; ok {
+			return "units: " + units + " " + tr("for") + " " + internalLocation.City + ", " + globalUnits() + " " + tr("elsewhere"), nil
+		}
+		return "units: " + globalUnits(), nil
+	}
+
+	if args[0] == "--for-location" {
+		return setLocationUnits(key, args[1:])
+	}
+
+	if len(args) > 1 || !isUnits(args[0]) {
+		return "", errUsage("units")
+	}
+
+	snapshotUnits = ""
+	config.Units = args[0]
+
+	if err := saveConfig(); err != nil {
+		return "  " + tr("units set to ") + config.Units + ", but could not be saved: " + err.Error(), nil
+	}
+
+	if units, ok := config.LocationUnits[key]; ok {
+		return "  " + tr("units set to ") + config.Units + ", " + fmt.Sprintf(tr("but %s still uses %s"), internalLocation.City, units), nil
+	}
+
+	return "  " + tr("units set to ") + config.Units, nil
+}
+
+// setLocationUnits binds units to the location saved under key, or with off, goes back to the global units there.
+func setLocationUnits(key string, args []string) (string, error) {
+
+	if len(args) != 1 || (args[0] != "off" && !isUnits(args[0])) {
+		return "", errUsage("units")
+	}
+
+	snapshotUnits = ""
+	message := "  " + tr("units set to ") + args[0] + " " + tr("for") + " " + internalLocation.City
+
+	if args[0] == "off" {
+		delete(config.LocationUnits, key)
+		message = "  " + tr("units set to ") + globalUnits() + " " + tr("for") + " " + internalLocation.City
+	} else {
+		config.LocationUnits[key] = args[0]
+	}
+
+	if err := saveConfig(); err != nil {
+		return message + ", but could not be saved: " + err.Error(), nil
+	}
+
+	return message, nil
+}
@@ -0,0 +1,757 @@
+package main
+
+import (
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestComfortDelta(t *testing.T) {
+	useTestState(t)
+	config.ComfortTemperature = 21
+
+	tests := []struct {
+		units   string
+		celsius float64
+		want    string
+	}{
+		{"metric", 24, "+3°C warmer"},
+		{"metric", 18, "-3°C cooler"},
+		{"metric", 21, "just right"},
+
+		// shown the same once rounded, so there's no difference to show
+		{"metric", 21.3, "just right"},
+
+		// 21°C is 70°F, so 18°C is 64°F and shown 6 degrees cooler
+		{"imperial", 18, "-6°F cooler"},
+	}
+
+	for _, test := range tests {
+		config.Units = test.units
+		if got := comfortDelta(test.celsius); got != test.want {
+			t.Errorf("with units %s, comfortDelta(%v) = %q, want %q", test.units, test.celsius, got, test.want)
+		}
+	}
+}
+
+func TestDescribeNowRelative(t *testing.T) {
+	useTestState(t)
+	config.ComfortTemperature = 20
+
+	c := Conditions{Temperature: 25, Code: 0}
+
+	if text := describeNow(c, nil, false); strings.Contains(text, "warmer") {
+		t.Errorf("without relative, described as %q", text)
+	}
+	if text := describeNow(c, nil, true); !strings.Contains(text, "+5°C warmer") {
+		t.Errorf("with relative, described as %q, want +5°C warmer in it", text)
+	}
+}
+
+func TestTemperatureConversions(t *testing.T) {
+
+	tests := []struct{ celsius, fahrenheit float64 }{
+		{0, 32},
+		{100, 212},
+		{-40, -40},
+		{37, 98.6},
+	}
+
+	for _, test := range tests {
+		if got := celsiusToFahrenheit(test.celsius); !approximately(got, test.fahrenheit) {
+			t.Errorf("celsiusToFahrenheit(%v) = %v, want %v", test.celsius, got, test.fahrenheit)
+		}
+		if got := fahrenheitToCelsius(test.fahrenheit); !approximately(got, test.celsius) {
+			t.Errorf("fahrenheitToCelsius(%v) = %v, want %v", test.fahrenheit, got, test.celsius)
+		}
+	}
+}
+
+func TestKmhToMph(t *testing.T) {
+
+	tests := []struct{ kmh, mph float64 }{
+		{0, 0},
+		{1.609344, 1},
+		{160.9344, 100},
+	}
+
+	for _, test := range tests {
+		if got := kmhToMph(test.kmh); !approximately(got, test.mph) {
+			t.Errorf("kmhToMph(%v) = %v, want %v", test.kmh, got, test.mph)
+		}
+	}
+}
+
+func TestFormatTemperatureAndSpeed(t *testing.T) {
+	useTestState(t)
+
+	tests := []struct {
+		units       string
+		temperature string
+		speed       string
+	}{
+		{"metric", "100°C", "100 km/h"},
+		{"imperial", "212°F", "62 mph"},
+		{"both", "100°C (212°F)", "100 km/h (62 mph)"},
+	}
+
+	for _, test := range tests {
+		config.Units = test.units
+		if got := formatTemperature(100); got != test.temperature {
+			t.Errorf("with units %s, formatTemperature(100) = %q, want %q", test.units, got, test.temperature)
+		}
+		if got := formatSpeed(100); got != test.speed {
+			t.Errorf("with units %s, formatSpeed(100) = %q, want %q", test.units, got, test.speed)
+		}
+	}
+}
+
+func TestApparentTemperature(t *testing.T) {
+
+	tests := []struct {
+		name                    string
+		celsius, humidity, wind float64
+		want                    float64
+	}{
+		// Environment Canada's wind chill table has -10°C at 20 km/h as -18
+		{"wind chill", -10, 50, 20, -17.9},
+		{"wind chill at 10°C", 10, 50, 30, 6.6},
+
+		// the National Weather Service's heat index chart has 90°F at 70% as 106°F
+		{"heat index", fahrenheitToCelsius(90), 70, 10, fahrenheitToCelsius(105.9)},
+
+		// too little wind for a chill, too cool for the heat index, or in between
+		{"calm", -5, 50, 4.8, -5},
+		{"mild", 18, 90, 40, 18},
+		{"just under the heat index", 26.6, 90, 0, 26.6},
+	}
+
+	for _, test := range tests {
+		got := apparentTemperature(test.celsius, test.humidity, test.wind)
+		if math.Abs(got-test.want) > 0.1 {
+			t.Errorf("%s: apparentTemperature(%v, %v, %v) = %.2f, want %.1f", test.name, test.celsius, test.humidity, test.wind, got, test.want)
+		}
+	}
+}
+
+func TestFeelsLike(t *testing.T) {
+
+	if got := feelsLike(Conditions{Temperature: 30, FeelsLike: float(33)}); got != 33 {
+		t.Errorf("with the provider's own value, feelsLike = %v, want 33", got)
+	}
+
+	// the heat index needs humidity, so without it there's nothing to go on but the temperature
+	if got := feelsLike(Conditions{Temperature: 32}); got != 32 {
+		t.Errorf("hot without humidity, feelsLike = %v, want 32", got)
+	}
+
+	if got := feelsLike(Conditions{Temperature: -10, WindSpeed: float(20)}); math.Abs(got+17.9) > 0.1 {
+		t.Errorf("cold and windy, feelsLike = %v, want the wind chill", got)
+	}
+}
+
+func TestCompassPoint(t *testing.T) {
+
+	tests := []struct {
+		degrees float64
+		want    string
+	}{
+		{0, "N"},
+		{360, "N"},
+		{720, "N"},
+		{-10, "N"},
+		{11.24, "N"},
+		{11.25, "NNE"},
+		{45, "NE"},
+		{90, "E"},
+		{180, "S"},
+		{247, "WSW"},
+		{270, "W"},
+		{348.74, "NNW"},
+
+		// N runs from 348.75° around to 11.25°
+		{348.75, "N"},
+		{359.9, "N"},
+		{-90, "W"},
+	}
+
+	for _, test := range tests {
+		if got := compassPoint(test.degrees); got != test.want {
+			t.Errorf("compassPoint(%v) = %s, want %s", test.degrees, got, test.want)
+		}
+	}
+}
+
+func TestWindArrow(t *testing.T) {
+
+	// the arrow points where the wind blows toward, so a north wind points down
+	tests := []struct {
+		fromDegrees float64
+		want        string
+	}{
+		{0, "↓"},
+		{360, "↓"},
+		{45, "↙"},
+		{90, "←"},
+		{180, "↑"},
+		{225, "↗"},
+		{270, "→"},
+		{315, "↘"},
+		{348.75, "↓"},
+		{-45, "↘"},
+	}
+
+	for _, test := range tests {
+		if got := windArrow(test.fromDegrees); got != test.want {
+			t.Errorf("windArrow(%v) = %s, want %s", test.fromDegrees, got, test.want)
+		}
+	}
+}
+
+func TestFormatWind(t *testing.T) {
+	useTestState(t)
+
+	tests := []struct {
+		c    Conditions
+		want string
+	}{
+		{Conditions{}, ""},
+		{Conditions{WindSpeed: float(12)}, "12 km/h"},
+		{Conditions{WindSpeed: float(12), WindDirection: float(315), WindGusts: float(28)}, "NW ↘ 12 km/h (gusts 28)"},
+	}
+
+	for _, test := range tests {
+		if got := formatWind(test.c); got != test.want {
+			t.Errorf("formatWind = %q, want %q", got, test.want)
+		}
+	}
+}
+
+// shortForecastProvider is the offline provider with a forecast only two days ahead
+type shortForecastProvider struct {
+	fakeProvider
+}
+
+func (shortForecastProvider) Window() (time.Duration, time.Duration) {
+	return 0, 48 * time.Hour
+}
+
+func TestDayOn(t *testing.T) {
+	useTestState(t)
+
+	today := time.Now().In(displayZone())
+	month, day := strconv.Itoa(int(today.Month())), strconv.Itoa(today.Day())
+
+	date, err := dayOn(month, day)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if date.Year() != today.Year() || date.Month() != today.Month() || date.Day() != today.Day() || date.Hour() != 0 {
+		t.Errorf("days on %s %s is %v, want the start of today", month, day, date)
+	}
+
+	for _, args := range [][2]string{{"2", "30"}, {"4", "31"}, {"13", "1"}, {"june", "0"}, {"june", "3rd"}, {"smarch", "1"}} {
+		if date, err := dayOn(args[0], args[1]); err == nil {
+			t.Errorf("days on %s %s = %v, want an error", args[0], args[1], date)
+		}
+	}
+
+	if today.YearDay() > 1 {
+		if _, err := dayOn("1", "1"); err == nil || !strings.Contains(err.Error(), "in the past") {
+			t.Errorf("days on 1 1 = %v, want it in the past", err)
+		}
+	}
+
+	provider = shortForecastProvider{}
+	if later := today.AddDate(0, 0, 5); later.Year() == today.Year() {
+		if _, err := dayOn(strconv.Itoa(int(later.Month())), strconv.Itoa(later.Day())); err == nil || !strings.Contains(err.Error(), "past the end") {
+			t.Errorf("5 days ahead with a 2 day forecast = %v, want it past the end", err)
+		}
+	}
+}
+
+func TestHighTrends(t *testing.T) {
+	useTestState(t)
+
+	days := func(highs ...float64) []DailyConditions {
+		var days []DailyConditions
+		for _, high := range highs {
+			days = append(days, DailyConditions{High: high})
+		}
+		return days
+	}
+
+	tests := []struct {
+		units string
+		highs []float64
+		want  []string
+	}{
+		{"metric", nil, nil},
+		{"metric", []float64{20}, []string{"-"}},
+		{"metric", []float64{20, 22, 19, 19}, []string{"-", "↑", "↓", "→"}},
+
+		// compared as they're shown, so 20.4° after 20.2° is level, and 20.6° after 20.4° is up
+		{"metric", []float64{20.2, 20.4, 20.6}, []string{"-", "→", "↑"}},
+
+		// in Fahrenheit, 20.2°C and 20.4°C are 68°F and 69°F
+		{"imperial", []float64{20.2, 20.4}, []string{"-", "↑"}},
+	}
+
+	for _, test := range tests {
+		config.Units = test.units
+		if got := highTrends(days(test.highs...)); !slices.Equal(got, test.want) {
+			t.Errorf("with units %s, highTrends(%v) = %q, want %q", test.units, test.highs, got, test.want)
+		}
+	}
+}
+
+func TestPressureTrend(t *testing.T) {
+
+	tests := []struct {
+		change float64
+		want   string
+	}{
+		{0, "→"},
+		{0.99, "→"},
+		{-0.99, "→"},
+		{steadyPressureChange, "↑"},
+		{4.5, "↑"},
+		{-steadyPressureChange, "↓"},
+		{-4.5, "↓"},
+	}
+
+	for _, test := range tests {
+		if got := pressureTrend(test.change); got != test.want {
+			t.Errorf("pressureTrend(%v) = %s, want %s", test.change, got, test.want)
+		}
+	}
+}
+
+func TestPressureInDescribeNow(t *testing.T) {
+	useTestState(t)
+
+	c := Conditions{Temperature: 15, Pressure: float(1012.5)}
+
+	tests := []struct {
+		units   string
+		earlier *float64
+		want    string
+	}{
+		{"metric", nil, "1012 hPa\n"},
+		{"metric", float(1010), "1012 hPa ↑ +2.5 hPa in 3h"},
+		{"metric", float(1012.8), "1012 hPa → -0.3 hPa in 3h"},
+		{"imperial", float(1016.5), "29.90 inHg ↓ -0.12 inHg in 3h"},
+	}
+
+	for _, test := range tests {
+		config.Units = test.units
+		if text := describeNow(c, test.earlier, false) + "\n"; !strings.Contains(text, test.want) {
+			t.Errorf("with units %s and earlier pressure %v, described as %q, want %q in it", test.units, test.earlier, text, test.want)
+		}
+	}
+}
+
+func TestRoundTemperature(t *testing.T) {
+	useTestState(t)
+
+	tests := []struct {
+		decimals int
+		degrees  float64
+		want     float64
+	}{
+		// halves round away from zero
+		{0, 2.5, 3},
+		{0, -2.5, -3},
+		{0, 3.5, 4},
+		{0, 0.5, 1},
+		{0, -0.5, -1},
+		{0, 2.49, 2},
+		{0, -2.49, -2},
+		{1, 2.25, 2.3},
+		{1, -2.25, -2.3},
+		{1, 18.04, 18},
+
+		// never -0°
+		{0, -0.4, 0},
+		{1, -0.04, 0},
+	}
+
+	for _, test := range tests {
+		config.TemperatureDecimals = test.decimals
+		got := roundTemperature(test.degrees)
+		if got != test.want || math.Signbit(got) != math.Signbit(test.want) {
+			t.Errorf("to %d decimals, roundTemperature(%v) = %v, want %v", test.decimals, test.degrees, got, test.want)
+		}
+	}
+}
+
+func TestDegrees(t *testing.T) {
+	useTestState(t)
+
+	tests := []struct {
+		decimals int
+		value    float64
+		want     string
+	}{
+		{0, -3.2, "-3°C"},
+		{0, -0.4, "0°C"},
+		{0, 2.5, "3°C"},
+		{1, 2.25, "2.3°C"},
+		{1, 18, "18.0°C"},
+	}
+
+	for _, test := range tests {
+		config.TemperatureDecimals = test.decimals
+		if got := degrees(test.value, "C"); got != test.want {
+			t.Errorf("to %d decimals, degrees(%v) = %q, want %q", test.decimals, test.value, got, test.want)
+		}
+	}
+}
+
+func TestSnapToHour(t *testing.T) {
+	useTestState(t)
+
+	nine := time.Date(2024, time.June, 3, 9, 0, 0, 0, time.UTC)
+	hours := hoursOf(nine, 10, 11, 12)
+
+	tests := []struct {
+		snapping string
+		minutes  int
+		want     int
+	}{
+		{"nearest", 0, 0},
+		{"nearest", 29, 0},
+
+		// half past goes to the later hour
+		{"nearest", 30, 1},
+		{"nearest", 90, 2},
+		{"nearest", 200, 2},
+		{"nearest", -30, 0},
+
+		{"floor", 0, 0},
+		{"floor", 59, 0},
+		{"floor", 60, 1},
+		{"floor", 119, 1},
+		{"floor", 200, 2},
+	}
+
+	for _, test := range tests {
+		config.HourSnapping = test.snapping
+		at := nine.Add(time.Duration(test.minutes) * time.Minute)
+		if got := snapToHour(at, hours); got != test.want {
+			t.Errorf("with %s snapping, snapToHour(%s) = %d, want %d", test.snapping, at.Format(time.Kitchen), got, test.want)
+		}
+	}
+}
+
+func TestSnappedStart(t *testing.T) {
+	useTestState(t)
+
+	at := time.Date(2024, time.June, 3, 9, 40, 0, 0, time.UTC)
+
+	config.HourSnapping = "nearest"
+	if got := snappedStart(at); got.Hour() != 10 {
+		t.Errorf("nearest to 9:40 starts at %v, want 10", got)
+	}
+
+	config.HourSnapping = "floor"
+	if got := snappedStart(at); got.Hour() != 9 {
+		t.Errorf("the hour 9:40 is in starts at %v, want 9", got)
+	}
+}
+
+func TestTemperatureExtremes(t *testing.T) {
+	useTestState(t)
+
+	start := time.Date(2024, time.June, 3, 9, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		units            string
+		temperatures     []float64
+		warmest, coldest int
+	}{
+		{"metric", nil, -1, -1},
+		{"metric", []float64{17}, -1, -1},
+		{"metric", []float64{17, 17, 17}, -1, -1},
+		{"metric", []float64{15, 19, 12, 18}, 1, 2},
+
+		// shown as 17° both, so the tie goes to the first
+		{"metric", []float64{16.8, 17.2, 12}, 0, 2},
+		{"metric", []float64{12, 11.6, 20, 19.6}, 2, 0},
+
+		// 16.8° and 17.2° are both shown as 17°, so there's nothing to pick out
+		{"metric", []float64{16.8, 17.2}, -1, -1},
+
+		// but in Fahrenheit they're 62°F and 63°F
+		{"imperial", []float64{16.8, 17.2}, 1, 0},
+	}
+
+	for _, test := range tests {
+		config.Units = test.units
+		warmest, coldest := temperatureExtremes(hoursOf(start, test.temperatures...))
+		if warmest != test.warmest || coldest != test.coldest {
+			t.Errorf("with units %s, temperatureExtremes(%v) = %d, %d, want %d, %d", test.units, test.temperatures, warmest, coldest, test.warmest, test.coldest)
+		}
+	}
+}
+
+func TestMagnusDewPoint(t *testing.T) {
+
+	// what dew point calculators give, to a tenth of a degree
+	tests := []struct{ celsius, humidity, want float64 }{
+		{20, 50, 9.3},
+		{30, 80, 26.2},
+		{0, 60, -6.8},
+		{-10, 70, -14.4},
+
+		// saturated air is at its dew point
+		{25, 100, 25},
+	}
+
+	for _, test := range tests {
+		if got := magnusDewPoint(test.celsius, test.humidity); math.Abs(got-test.want) > 0.06 {
+			t.Errorf("magnusDewPoint(%v, %v) = %.2f, want %.1f", test.celsius, test.humidity, got, test.want)
+		}
+	}
+}
+
+func TestDewPoint(t *testing.T) {
+
+	if got := dewPoint(Conditions{Temperature: 20, Humidity: float(50), DewPoint: float(11)}); got == nil || *got != 11 {
+		t.Errorf("with the provider's own dew point, dewPoint = %v, want 11", got)
+	}
+	if got := dewPoint(Conditions{Temperature: 20, Humidity: float(50)}); got == nil || math.Abs(*got-9.26) > 0.01 {
+		t.Errorf("from humidity, dewPoint = %v, want 9.26", got)
+	}
+
+	// with no humidity, or none at all, there's nothing to work it out from
+	for _, humidity := range []*float64{nil, float(0)} {
+		if got := dewPoint(Conditions{Temperature: 20, Humidity: humidity}); got != nil {
+			t.Errorf("with humidity %v, dewPoint = %v, want nil", humidity, *got)
+		}
+	}
+}
+
+func TestComfortLevel(t *testing.T) {
+
+	tests := []struct {
+		dewPoint float64
+		want     string
+	}{
+		{-5, "Dry"},
+		{9.9, "Dry"},
+		{10, "Comfortable"},
+		{15.9, "Comfortable"},
+		{16, "Humid"},
+		{20.9, "Humid"},
+		{21, "Oppressive"},
+		{28, "Oppressive"},
+	}
+
+	for _, test := range tests {
+		if got := comfortLevel(test.dewPoint); got != test.want {
+			t.Errorf("comfortLevel(%v) = %s, want %s", test.dewPoint, got, test.want)
+		}
+	}
+}
+
+func TestIsWindy(t *testing.T) {
+	useTestState(t)
+
+	tests := []struct {
+		units string
+		alert int
+		kmh   *float64
+		want  bool
+	}{
+		{"metric", 30, nil, false},
+		{"metric", 0, float(90), false},
+		{"metric", 30, float(29), false},
+		{"metric", 30, float(30), false},
+		{"metric", 30, float(31), true},
+
+		// compared as it's shown, so 30.4 and 30.5 km/h are both 30 km/h, and 30.6 is 31
+		{"metric", 30, float(30.4), false},
+		{"metric", 30, float(30.5), false},
+		{"metric", 30, float(30.6), true},
+
+		// with imperial, the alert is in mph: 48 km/h is 29.8 mph, shown as 30, and 50 km/h is 31 mph
+		{"imperial", 30, float(48), false},
+		{"imperial", 30, float(50), true},
+	}
+
+	for _, test := range tests {
+		config.Units, config.WindAlert = test.units, test.alert
+		if got := isWindy(test.kmh); got != test.want {
+			speed := "nil"
+			if test.kmh != nil {
+				speed = strconv.FormatFloat(*test.kmh, 'f', -1, 64)
+			}
+			t.Errorf("with units %s and wind-alert %d, isWindy(%s) = %v, want %v", test.units, test.alert, speed, got, test.want)
+		}
+	}
+}
+
+func TestAggregateDays(t *testing.T) {
+
+	zone, err := time.LoadLocation("America/Los_Angeles")
+	if err != nil {
+		t.Skip(err)
+	}
+
+	// 10pm June 2 to 3am June 3 in Los Angeles, which is 5am to 10am UTC on June 3
+	start := time.Date(2024, time.June, 2, 22, 0, 0, 0, zone)
+	hours := hoursOf(start, 14, 12, 11, 9, 10, 8)
+
+	codes := []int{3, 61, 61, 0, 2, 0}
+	chances := []*float64{nil, float(40), float(70), nil, float(10), nil}
+	winds := []*float64{float(12), nil, float(30), float(5), nil, float(8)}
+	for i := range hours {
+		hours[i].Code, hours[i].PrecipitationChance, hours[i].WindSpeed = codes[i], chances[i], winds[i]
+	}
+
+	days := aggregateDays(hours, zone)
+	if len(days) != 2 {
+		t.Fatalf("got %d days, want 2", len(days))
+	}
+
+	// dated at midnight UTC on the local date, like a provider's
+	if want := time.Date(2024, time.June, 2, 0, 0, 0, 0, time.UTC); !days[0].Date.Equal(want) {
+		t.Errorf("first day is %v, want %v", days[0].Date, want)
+	}
+	if want := time.Date(2024, time.June, 3, 0, 0, 0, 0, time.UTC); !days[1].Date.Equal(want) {
+		t.Errorf("second day is %v, want %v", days[1].Date, want)
+	}
+
+	first, second := days[0], days[1]
+	if first.High != 14 || first.Low != 12 || second.High != 11 || second.Low != 8 {
+		t.Errorf("highs and lows are %v/%v and %v/%v, want 14/12 and 11/8", first.High, first.Low, second.High, second.Low)
+	}
+
+	// 3 and 61 tie on the first day, so the higher code wins, and 0 is most of the second
+	if first.Code != 61 || second.Code != 0 {
+		t.Errorf("codes are %d and %d, want 61 and 0", first.Code, second.Code)
+	}
+
+	if first.PrecipitationChance == nil || *first.PrecipitationChance != 40 || second.PrecipitationChance == nil || *second.PrecipitationChance != 70 {
+		t.Errorf("chances are %v and %v, want 40 and 70", first.PrecipitationChance, second.PrecipitationChance)
+	}
+	if first.WindSpeed == nil || *first.WindSpeed != 12 || second.WindSpeed == nil || *second.WindSpeed != 30 {
+		t.Errorf("winds are %v and %v, want 12 and 30", first.WindSpeed, second.WindSpeed)
+	}
+
+	if days := aggregateDays(nil, zone); len(days) != 0 {
+		t.Errorf("no hours made %d days", len(days))
+	}
+}
+
+func TestAggregateDaysUnreported(t *testing.T) {
+
+	days := aggregateDays(hoursOf(time.Date(2024, time.June, 3, 0, 0, 0, 0, time.UTC), 10, 12), time.UTC)
+
+	if len(days) != 1 || days[0].PrecipitationChance != nil || days[0].WindSpeed != nil {
+		t.Errorf("with nothing reported, got %+v, want one day with no chance or wind", days)
+	}
+}
+
+func TestForecastLimit(t *testing.T) {
+	useTestState(t)
+	provider = shortForecastProvider{}
+
+	if err := forecastLimit(48, "hour"); err.Error() != "fake forecasts at most 48 hours" {
+		t.Errorf("forecastLimit(48, hour) = %q", err)
+	}
+	if err := forecastLimit(1, "day"); err.Error() != "fake forecasts at most 1 day" {
+		t.Errorf("forecastLimit(1, day) = %q", err)
+	}
+
+	// right up to the end of the forecast is fine, one past it isn't
+	if _, err := hoursForecast([]string{"48"}); err != nil {
+		t.Errorf("hours 48 with a 48 hour forecast: %v", err)
+	}
+	if _, err := hoursForecast([]string{"49"}); err == nil || err.Error() != "fake forecasts at most 48 hours" {
+		t.Errorf("hours 49 with a 48 hour forecast = %v", err)
+	}
+
+	if _, err := daysForecast([]string{"2"}); err != nil {
+		t.Errorf("days 2 with a 2 day forecast: %v", err)
+	}
+	if _, err := daysForecast([]string{"3"}); err == nil || err.Error() != "fake forecasts at most 2 days" {
+		t.Errorf("days 3 with a 2 day forecast = %v", err)
+	}
+}
+
+// listingLines is hourlyListing's lines, with any leading spaces trimmed
+func listingLines(hours []Conditions, headed time.Time) []string {
+	lines := strings.Split(hourlyListing(hours, headed, false), "\n")
+	for i := range lines {
+		lines[i] = strings.TrimSpace(lines[i])
+	}
+	return lines
+}
+
+func TestHourlyListingRollover(t *testing.T) {
+	useTestState(t)
+	zone := displayZone()
+
+	// into a new day, month and year, at 12AM on LOCATION's clock
+	start := time.Date(2024, time.December, 31, 22, 0, 0, 0, zone)
+	lines := listingLines(hoursOf(start, 1, 2, 3, 4), start)
+
+	if len(lines) != 5 || lines[2] != "— Wed Jan 1 —" {
+		t.Fatalf("listing from 10PM on New Year's Eve is\n%s\nwant Wed Jan 1 marked before 12AM", strings.Join(lines, "\n"))
+	}
+	if !strings.HasPrefix(lines[1], "11PM") || !strings.HasPrefix(lines[3], "12AM") {
+		t.Errorf("the date is marked between\n%s\nand\n%s", lines[1], lines[3])
+	}
+
+	// the end of a month that isn't the end of the year, over two midnights
+	start = time.Date(2024, time.February, 28, 23, 0, 0, 0, zone)
+	lines = listingLines(hoursOf(start, make([]float64, 26)...), start)
+
+	var markers []string
+	for _, line := range lines {
+		if strings.HasPrefix(line, "—") {
+			markers = append(markers, line)
+		}
+	}
+	if strings.Join(markers, ", ") != "— Thu Feb 29 —, — Fri Mar 1 —" {
+		t.Errorf("26 hours from 11PM on Feb 28 marked %q", markers)
+	}
+
+	// a listing that starts on another day than the header shows starts with its date
+	lines = listingLines(hoursOf(start, 1, 2), start.Add(-24*time.Hour))
+	if lines[0] != "— Wed Feb 28 —" {
+		t.Errorf("a listing headed the day before starts with %q", lines[0])
+	}
+
+	// and one within a day has none
+	start = time.Date(2024, time.June, 3, 9, 0, 0, 0, zone)
+	for _, line := range listingLines(hoursOf(start, 1, 2, 3), start) {
+		if strings.HasPrefix(line, "—") {
+			t.Errorf("listing within one day marked %q", line)
+		}
+	}
+}
+
+func TestNowOneline(t *testing.T) {
+	useTestState(t)
+
+	output, err := nowWeather([]string{"--oneline"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	line, ok := strings.CutPrefix(output, unindented)
+	if !ok || !strings.HasPrefix(line, internalLocation.City+": ") || !strings.Contains(line, ", wind ") || strings.Contains(line, "\n") {
+		t.Errorf("now --oneline = %q, want one unindented line", output)
+	}
+
+	// printed as it is, where the usual output is indented
+	if printed := captureStdout(t, func() { printOutput(output) }); printed != line+"\n" {
+		t.Errorf("now --oneline printed %q, want %q", printed, line+"\n")
+	}
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// askField asks for one settime field until it gets a valid answer, using the current value when the answer is
+// blank. parse returns the value it was given as the number settime would store.
+func askField(name string, current string, parse func(string) (int, error)) (int, error) {
+
+	for {
+		answer, err := ask(fmt.Sprintf("  %s [%s]: ", name, current))
+		if err != nil {
+			return 0, err
+		}
+
+		answer = strings.TrimSpace(answer)
+		if answer == "" {
+			answer = current
+		}
+
+		value, err := parse(answer)
+		if err == nil {
+			return value, nil
+		}
+
+		printError(err)
+	}
+}
+
+func parseWizardHour(value string) (int, error) {
+
+	hour, err := parseHour(value)
+	if err != nil {
+		return 0, err
+	}
+
+	if hour < 0 || hour > 23 {
+		return 0, errors.New("Expected Hour in range 0-23, got " + value)
+	}
+
+	return hour, nil
+}
+
+func parseWizardYear(value string) (int, error) {
+
+	year, err := parseYear(value)
+	if err != nil {
+		return 0, err
+	}
+
+	if year < minYear || year > maxYear {
+		return 0, errors.New("Expected a Year from " + strconv.Itoa(minYear) + " to " + strconv.Itoa(maxYear) + ", got " + value)
+	}
+
+	return year, nil
+}
+
+// dayParser checks a day against the month and year it will end up in, once those are known.
+func dayParser(month *int, year *int) func(string) (int, error) {
+	return func(value string) (int, error) {
+
+		day, err := parseDay(value)
+		if err != nil {
+			return 0, err
+		}
+
+		limit := 31
+		if *month != 0 {
+			limit = daysIn(time.Month(*month), *year)
+		}
+
+		if day < 1 || day > limit {
+			return 0, errors.New("Expected Day in range 1-" + strconv.Itoa(limit) + ", got " + value)
+		}
+
+		return day, nil
+	}
+}
+
+// timeWizard asks for each part of TIME in turn, then sets it the same way settime HOUR DAY MONTH YEAR would.
+func timeWizard() (string, error) {
+
+	cancelled := errors.New("settime wizard cancelled, TIME is unchanged")
+
+	hour, err := askField("Hour", strconv.Itoa(internalTime.Hour()), parseWizardHour)
+	if err != nil {
+		return "", cancelled
+	}
+
+	month, year := 0, internalTime.Year()
+	checkDay := dayParser(&month, &year)
+
+	day, err := askField("Day", strconv.Itoa(internalTime.Day()), checkDay)
+	if err != nil {
+		return "", cancelled
+	}
+
+	month, err = askField("Month", monthName(internalTime.Month()), parseMonth)
+	if err != nil {
+		return "", cancelled
+	}
+
+	year, err = askField("Year", strconv.Itoa(internalTime.Year()), parseWizardYear)
+	if err != nil {
+		return "", cancelled
+	}
+
+	// the day was asked for before the month and year it belongs to, so it may need asking again
+	if _, err := checkDay(strconv.Itoa(day)); err != nil {
+		printError(err)
+		if day, err = askField("Day", strconv.Itoa(daysIn(time.Month(month), year)), checkDay); err != nil {
+			return "", cancelled
+		}
+	}
+
+	return setTime([]string{strconv.Itoa(hour), strconv.Itoa(day), strconv.Itoa(month), strconv.Itoa(year)})
+}